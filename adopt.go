@@ -0,0 +1,149 @@
+package mangrove
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AdoptWorktree is an ad-hoc worktree of a profile repo.
+type AdoptWorktree struct {
+	Repo Repo
+	Path string
+}
+
+// AdoptCandidate is a group of ad-hoc worktrees across a profile's repos that
+// share a branch and can be adopted as one workspace.
+type AdoptCandidate struct {
+	Branch    string
+	Worktrees []AdoptWorktree
+}
+
+// Label returns a label for fzf selection, e.g. "feature/login  (api, web)".
+func (c *AdoptCandidate) Label() string {
+	names := make([]string, len(c.Worktrees))
+	for i, wt := range c.Worktrees {
+		names[i] = wt.Repo.Name
+	}
+	return fmt.Sprintf("%s  (%s)", c.Branch, strings.Join(names, ", "))
+}
+
+// FindAdoptCandidates scans the worktrees of every profile repo and groups the
+// ones that live outside base_dir by branch. The main worktree, detached, locked
+// and prunable worktrees are skipped.
+func FindAdoptCandidates(cfg *Config, profile *Profile) ([]AdoptCandidate, error) {
+	baseDir := canonicalPath(cfg.BaseDir)
+	groups := make(map[string]*AdoptCandidate)
+
+	for _, repo := range profile.Repos {
+		entries, err := WorktreeList(repo.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", repo.Name, err)
+		}
+
+		for i, e := range entries {
+			if i == 0 || e.Bare || e.Detached || e.Locked || e.Prunable || e.Branch == "" {
+				continue
+			}
+			rel, err := filepath.Rel(baseDir, canonicalPath(e.Worktree))
+			if err == nil && !strings.HasPrefix(rel, "..") {
+				continue
+			}
+
+			branch := strings.TrimPrefix(e.Branch, "refs/heads/")
+			c, ok := groups[branch]
+			if !ok {
+				c = &AdoptCandidate{Branch: branch}
+				groups[branch] = c
+			}
+			c.Worktrees = append(c.Worktrees, AdoptWorktree{Repo: repo, Path: e.Worktree})
+		}
+	}
+
+	candidates := make([]AdoptCandidate, 0, len(groups))
+	for _, c := range groups {
+		candidates = append(candidates, *c)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Branch < candidates[j].Branch
+	})
+	return candidates, nil
+}
+
+// AdoptWorkspace moves the worktrees of a candidate into a new workspace under
+// base_dir with git worktree move and records its metadata, so the work shows up
+// in list, status and rm. If a move fails, the worktrees already moved are moved back.
+func AdoptWorkspace(cfg *Config, profileName, name string, candidate AdoptCandidate) error {
+	if err := ValidateWorkspaceName(name); err != nil {
+		return err
+	}
+
+	wsPath := GetWorkspacePath(cfg, profileName, name)
+
+	if _, err := os.Stat(wsPath); err == nil {
+		return fmt.Errorf("workspace %q already exists at %s", name, wsPath)
+	}
+
+	if err := os.MkdirAll(wsPath, 0o755); err != nil {
+		return fmt.Errorf("failed to create workspace directory: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nAdopting worktrees into: %s/%s\n", profileName, name)
+
+	var moved []AdoptWorktree
+	for _, wt := range candidate.Worktrees {
+		target := filepath.Join(wsPath, wt.Repo.Name)
+		if err := WorktreeMove(wt.Repo.Path, wt.Path, target); err != nil {
+			err = fmt.Errorf("failed to move worktree for %s: %w", wt.Repo.Name, err)
+			return errors.Join(err, undoAdopt(wsPath, moved))
+		}
+		moved = append(moved, wt)
+		PrintSuccess("%s  %s → %s",
+			RepoNameStyle.Render(wt.Repo.Name),
+			DimStyle.Render(CollapsePath(wt.Path)),
+			CollapsePath(target),
+		)
+	}
+
+	meta := &WorkspaceMetadata{
+		Profile:    profileName,
+		Name:       name,
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+		MgvVersion: Version,
+	}
+	for _, wt := range candidate.Worktrees {
+		base := wt.Repo.GetDefaultBase()
+		commit, _ := MergeBase(filepath.Join(wsPath, wt.Repo.Name), base, "HEAD")
+		meta.Repos = append(meta.Repos, RepoMetadata{
+			Name:       wt.Repo.Name,
+			Branch:     candidate.Branch,
+			Base:       base,
+			BaseCommit: commit,
+		})
+	}
+
+	if err := SaveWorkspaceMetadata(wsPath, meta); err != nil {
+		return errors.Join(err, undoAdopt(wsPath, moved))
+	}
+
+	fmt.Fprintf(os.Stderr, "\nWorkspace ready: %s\n", wsPath)
+	return nil
+}
+
+// undoAdopt moves adopted worktrees back to where they were and removes the workspace directory.
+func undoAdopt(wsPath string, moved []AdoptWorktree) error {
+	var errs []error
+	for _, wt := range moved {
+		if err := WorktreeMove(wt.Repo.Path, filepath.Join(wsPath, wt.Repo.Name), wt.Path); err != nil {
+			errs = append(errs, fmt.Errorf("failed to move %s back to %s: %w", wt.Repo.Name, wt.Path, err))
+		}
+	}
+	if len(errs) == 0 {
+		_ = os.RemoveAll(wsPath)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,82 @@
+package mangrove
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindAdoptCandidatesAndAdopt(t *testing.T) {
+	api := newTestRepo(t)
+	web := newTestRepo(t)
+	adhoc := t.TempDir()
+	runGit(t, api, "worktree", "add", "-b", "feature/login", filepath.Join(adhoc, "api-login"), "main")
+	runGit(t, web, "worktree", "add", "-b", "feature/login", filepath.Join(adhoc, "web-login"), "main")
+	runGit(t, web, "worktree", "add", "-b", "spike", filepath.Join(adhoc, "web-spike"), "main")
+	runGit(t, web, "worktree", "add", "--detach", filepath.Join(adhoc, "web-detached"), "main")
+
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: []Repo{{Name: "api", Path: api}, {Name: "web", Path: web}}}
+
+	candidates, err := FindAdoptCandidates(cfg, profile)
+	if err != nil {
+		t.Fatalf("FindAdoptCandidates() unexpected error: %v", err)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("FindAdoptCandidates() returned %d candidates, want 2: %+v", len(candidates), candidates)
+	}
+	login := candidates[0]
+	if login.Branch != "feature/login" || len(login.Worktrees) != 2 {
+		t.Fatalf("candidates[0] = %+v, want feature/login in api and web", login)
+	}
+	if got := login.Label(); got != "feature/login  (api, web)" {
+		t.Errorf("Label() = %q", got)
+	}
+
+	if err := AdoptWorkspace(cfg, "proj", "login", login); err != nil {
+		t.Fatalf("AdoptWorkspace() unexpected error: %v", err)
+	}
+
+	wsPath := GetWorkspacePath(cfg, "proj", "login")
+	for _, name := range []string{"api", "web"} {
+		branch, err := CurrentBranch(filepath.Join(wsPath, name))
+		if err != nil {
+			t.Fatalf("CurrentBranch(%s) unexpected error: %v", name, err)
+		}
+		if branch != "feature/login" {
+			t.Errorf("%s branch = %q, want feature/login", name, branch)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(adhoc, "api-login")); !os.IsNotExist(err) {
+		t.Error("adopted worktree should be moved out of its old location")
+	}
+
+	meta, err := LoadWorkspaceMetadata(wsPath)
+	if err != nil || meta == nil {
+		t.Fatalf("LoadWorkspaceMetadata() = %v, %v; want metadata", meta, err)
+	}
+	if rm := meta.FindRepo("web"); rm == nil || rm.Branch != "feature/login" || rm.Base != "main" {
+		t.Errorf("web metadata = %+v, want branch feature/login base main", rm)
+	}
+
+	// Adopted worktrees live under base_dir and are no longer candidates
+	candidates, err = FindAdoptCandidates(cfg, profile)
+	if err != nil {
+		t.Fatalf("FindAdoptCandidates() unexpected error: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].Branch != "spike" {
+		t.Errorf("FindAdoptCandidates() after adopt = %+v, want only spike", candidates)
+	}
+
+	if err := AdoptWorkspace(cfg, "proj", "login", candidates[0]); err == nil {
+		t.Error("AdoptWorkspace() expected error for an existing workspace")
+	}
+
+	// A branch name is not a valid workspace name as it is
+	if err := AdoptWorkspace(cfg, "proj", "feature/spike", candidates[0]); err == nil {
+		t.Error("AdoptWorkspace() expected error for a name with a path separator")
+	}
+	if _, err := os.Stat(filepath.Join(adhoc, "web-spike")); err != nil {
+		t.Errorf("spike worktree should stay in place: %v", err)
+	}
+}
@@ -0,0 +1,654 @@
+package mangrove
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ApplyStateFileName is the name of the file that journals an in-progress apply in the workspace root.
+const ApplyStateFileName = ".mgv-apply.yaml"
+
+// applyScratchDir holds the temporary worktrees of an isolated apply, one per repo, in the workspace root.
+const applyScratchDir = ".mgv-apply"
+
+// Apply methods.
+const (
+	// ApplyStash moves the worktree's uncommitted changes onto a new branch in the original repo.
+	ApplyStash = "stash"
+	// ApplyMerge merges the worktree branch into a new branch in the original repo.
+	ApplyMerge = "merge"
+	// ApplyRebase replays the worktree branch's commits onto the base on a new branch.
+	ApplyRebase = "rebase"
+	// ApplySquash commits the worktree branch's changes as a single commit on a new branch.
+	ApplySquash = "squash"
+	// ApplyCherryPick applies selected commits of the worktree branch on a new branch.
+	ApplyCherryPick = "cherry-pick"
+)
+
+// ApplyMethods lists every apply method.
+var ApplyMethods = []string{ApplyStash, ApplyMerge, ApplyRebase, ApplySquash, ApplyCherryPick}
+
+// Apply repo states.
+const (
+	applyPending  = "pending"
+	applyDone     = "done"
+	applyConflict = "conflict"
+)
+
+// Apply steps, journaled in order as each one completes so they can be undone.
+const (
+	// applyStepStash: the worktree changes were stashed (ApplyRepoState.Stash).
+	applyStepStash = "stash"
+	// applyStepCheckout: the original repo (or, when isolated, a temporary worktree)
+	// was switched to the new branch.
+	applyStepCheckout = "checkout"
+	// applyStepChanges: the changes were applied on the new branch.
+	applyStepChanges = "changes"
+	// applyStepReturn: the original repo was switched back to its original branch,
+	// or the temporary worktree was removed.
+	applyStepReturn = "return"
+)
+
+// ApplyTarget describes how one repo's worktree is applied to the original repo.
+type ApplyTarget struct {
+	RepoName string
+	RepoPath string
+	Worktree string
+	Method   string
+	// Branch is the worktree branch, whose commits are applied by every method but ApplyStash.
+	Branch string
+	// NewBranch is created in the original repo from Base.
+	NewBranch string
+	Base      string
+	// Message is the commit message of ApplySquash, and of ApplyStash when isolated.
+	Message string
+	// Commits are the commits applied by ApplyCherryPick, oldest first.
+	Commits []string
+	// Isolated builds the new branch in a temporary worktree, so the original repo's
+	// HEAD and working tree are never touched. Stashed changes are committed.
+	Isolated bool
+}
+
+// ApplyRepoState journals the progress of a single repo within an apply.
+type ApplyRepoState struct {
+	Name       string   `yaml:"name"`
+	RepoPath   string   `yaml:"repo_path"`
+	Worktree   string   `yaml:"worktree"`
+	Method     string   `yaml:"method"`
+	Branch     string   `yaml:"branch"`
+	NewBranch  string   `yaml:"new_branch"`
+	Base       string   `yaml:"base"`
+	Message    string   `yaml:"message,omitempty"`
+	Commits    []string `yaml:"commits,omitempty"`
+	Scratch    string   `yaml:"scratch,omitempty"`
+	OrigBranch string   `yaml:"orig_branch,omitempty"`
+	OrigHead   string   `yaml:"orig_head,omitempty"`
+	Stash      string   `yaml:"stash,omitempty"`
+	Steps      []string `yaml:"steps,omitempty"`
+	Status     string   `yaml:"status"`
+}
+
+// workDir returns the directory the new branch is built in: the temporary worktree
+// of an isolated apply, or the original repo.
+func (rs *ApplyRepoState) workDir() string {
+	if rs.Scratch != "" {
+		return rs.Scratch
+	}
+	return rs.RepoPath
+}
+
+// ApplyState is the journal of an in-progress apply, used by --continue and --abort.
+type ApplyState struct {
+	Repos []ApplyRepoState `yaml:"repos"`
+}
+
+// Applied returns the repos that were applied.
+func (s *ApplyState) Applied() []ApplyRepoState {
+	var done []ApplyRepoState
+	for _, rs := range s.Repos {
+		if rs.Status == applyDone {
+			done = append(done, rs)
+		}
+	}
+	return done
+}
+
+// ApplyConflictError is returned when an apply stops on a conflict in the original repo.
+type ApplyConflictError struct {
+	RepoName string
+	// RepoPath is where the conflict is left: the original repo, or the temporary
+	// worktree of an isolated apply.
+	RepoPath string
+	Method   string
+}
+
+func (e *ApplyConflictError) Error() string {
+	action := "merging"
+	switch e.Method {
+	case ApplyStash:
+		action = "applying the stashed changes"
+	case ApplyRebase:
+		action = "rebasing"
+	case ApplySquash:
+		action = "squashing"
+	case ApplyCherryPick:
+		action = "cherry-picking"
+	}
+	return fmt.Sprintf("%s: conflict while %s in %s", e.RepoName, action, e.RepoPath)
+}
+
+// ErrNoApplyInProgress is returned by ContinueApply and AbortApply when there is nothing to resume.
+var ErrNoApplyInProgress = errors.New("no apply in progress")
+
+// LoadApplyState reads the apply journal of a workspace.
+// Returns nil without error if no apply is in progress.
+func LoadApplyState(wsPath string) (*ApplyState, error) {
+	data, err := os.ReadFile(filepath.Join(wsPath, ApplyStateFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read apply state: %w", err)
+	}
+
+	var state ApplyState
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse apply state: %w", err)
+	}
+	return &state, nil
+}
+
+// saveApplyState writes the apply journal of a workspace.
+func saveApplyState(wsPath string, state *ApplyState) error {
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal apply state: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(wsPath, ApplyStateFileName), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write apply state: %w", err)
+	}
+	return nil
+}
+
+// removeApplyState deletes the apply journal of a workspace.
+func removeApplyState(wsPath string) error {
+	err := os.Remove(filepath.Join(wsPath, ApplyStateFileName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove apply state: %w", err)
+	}
+	return nil
+}
+
+// StartApply applies each target to its original repo, one repo at a time, journaling
+// every step in the workspace. It stops on the first conflict and returns an
+// *ApplyConflictError, leaving the conflict in place for the user to resolve.
+// Any other failure undoes that repo's steps and keeps the journal, so the apply can
+// be retried with ContinueApply or rolled back with AbortApply.
+// On success the journal is removed and the final state is returned.
+func StartApply(wsPath string, targets []ApplyTarget) (*ApplyState, error) {
+	if existing, err := LoadApplyState(wsPath); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, fmt.Errorf("an apply is already in progress. Run mgv apply --continue or --abort")
+	}
+	if existing, err := LoadSyncState(wsPath); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, fmt.Errorf("a sync is in progress. Finish it with mgv sync --continue or --abort first")
+	}
+
+	wsPath, err := filepath.Abs(wsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &ApplyState{}
+	for _, t := range targets {
+		if !slices.Contains(ApplyMethods, t.Method) {
+			return nil, fmt.Errorf("%s: unknown apply method %q", t.RepoName, t.Method)
+		}
+		if t.Method == ApplySquash && t.Message == "" {
+			return nil, fmt.Errorf("%s: a commit message is required to squash", t.RepoName)
+		}
+		if t.Method == ApplyStash && t.Isolated && t.Message == "" {
+			return nil, fmt.Errorf("%s: a commit message is required to apply the stash in isolation", t.RepoName)
+		}
+		if t.Method == ApplyCherryPick && len(t.Commits) == 0 {
+			return nil, fmt.Errorf("%s: no commits to cherry-pick", t.RepoName)
+		}
+		state.Repos = append(state.Repos, ApplyRepoState{
+			Name:      t.RepoName,
+			RepoPath:  t.RepoPath,
+			Worktree:  t.Worktree,
+			Method:    t.Method,
+			Branch:    t.Branch,
+			NewBranch: t.NewBranch,
+			Base:      t.Base,
+			Message:   t.Message,
+			Commits:   t.Commits,
+			Status:    applyPending,
+		})
+		if t.Isolated {
+			state.Repos[len(state.Repos)-1].Scratch = filepath.Join(wsPath, applyScratchDir, t.RepoName)
+		}
+	}
+
+	if err := saveApplyState(wsPath, state); err != nil {
+		return nil, err
+	}
+	return runApply(wsPath, state)
+}
+
+// ContinueApply resumes an apply after the user has resolved the conflict in the
+// original repo, or retries a repo that failed.
+func ContinueApply(wsPath string) (*ApplyState, error) {
+	state, err := LoadApplyState(wsPath)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, ErrNoApplyInProgress
+	}
+
+	for i := range state.Repos {
+		rs := &state.Repos[i]
+		if rs.Status != applyConflict {
+			continue
+		}
+
+		if err := resolveApplyConflict(rs); err != nil {
+			return nil, err
+		}
+		rs.Steps = append(rs.Steps, applyStepChanges)
+		if err := finishApplyRepo(wsPath, state, rs); err != nil {
+			return nil, err
+		}
+	}
+
+	return runApply(wsPath, state)
+}
+
+// AbortApply undoes every step of an in-progress apply in reverse order: the original
+// repos are reset to their original branch, new branches are deleted and stashed
+// changes are put back in the worktrees.
+func AbortApply(wsPath string) error {
+	state, err := LoadApplyState(wsPath)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return ErrNoApplyInProgress
+	}
+
+	var errs []error
+	for i := len(state.Repos) - 1; i >= 0; i-- {
+		rs := &state.Repos[i]
+		if len(rs.Steps) == 0 {
+			continue
+		}
+		if err := undoApplyRepo(rs); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rs.Name, err))
+			continue
+		}
+		rs.Status = applyPending
+		PrintSuccess("%s  restored", RepoNameStyle.Render(rs.Name))
+	}
+	removeScratchDir(wsPath)
+
+	if err := errors.Join(errs...); err != nil {
+		// Keep what could not be undone so --abort can be retried
+		if serr := saveApplyState(wsPath, state); serr != nil {
+			return errors.Join(err, serr)
+		}
+		return err
+	}
+	return removeApplyState(wsPath)
+}
+
+// runApply processes every pending repo in order, stopping on the first conflict or
+// failure. Once all repos are done the worktree stashes are dropped and the journal removed.
+func runApply(wsPath string, state *ApplyState) (*ApplyState, error) {
+	for i := range state.Repos {
+		rs := &state.Repos[i]
+		if rs.Status != applyPending {
+			continue
+		}
+
+		err := applyRepo(wsPath, state, rs)
+		var conflict *ApplyConflictError
+		if errors.As(err, &conflict) {
+			rs.Status = applyConflict
+			if serr := saveApplyState(wsPath, state); serr != nil {
+				return nil, serr
+			}
+			return nil, err
+		}
+		if err != nil {
+			// Leave the repo as it was so the apply can be retried or aborted
+			if uerr := undoApplyRepo(rs); uerr != nil {
+				err = errors.Join(err, fmt.Errorf("rollback failed: %w", uerr))
+			}
+			if serr := saveApplyState(wsPath, state); serr != nil {
+				err = errors.Join(err, serr)
+			}
+			return nil, fmt.Errorf("%s: %w", rs.Name, err)
+		}
+	}
+
+	// Every repo is applied: the stashed changes now live in the original repos
+	for _, rs := range state.Repos {
+		if rs.Stash != "" {
+			if err := StashDropCommit(rs.Worktree, rs.Stash); err != nil {
+				PrintWarning("%s: failed to drop stash %s: %v", rs.Name, shortHash(rs.Stash), err)
+			}
+		}
+	}
+	removeScratchDir(wsPath)
+	if err := removeApplyState(wsPath); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// removeScratchDir removes the directory of the temporary worktrees once it is empty.
+func removeScratchDir(wsPath string) {
+	_ = os.Remove(filepath.Join(wsPath, applyScratchDir))
+}
+
+// applyRepo runs the remaining steps of one repo, journaling each one.
+func applyRepo(wsPath string, state *ApplyState, rs *ApplyRepoState) error {
+	step := func(name string) error {
+		rs.Steps = append(rs.Steps, name)
+		return saveApplyState(wsPath, state)
+	}
+
+	if rs.OrigBranch == "" && rs.Scratch == "" {
+		branch, err := CurrentBranch(rs.RepoPath)
+		if err != nil {
+			return err
+		}
+		head, err := RevParse(rs.RepoPath, "HEAD")
+		if err != nil {
+			return err
+		}
+		rs.OrigBranch, rs.OrigHead = branch, head
+	}
+
+	if rs.Method == ApplyStash {
+		if err := StashPushUntracked(rs.Worktree, "mgv-apply: "+rs.NewBranch); err != nil {
+			return err
+		}
+		stash, err := RevParse(rs.Worktree, "refs/stash")
+		if err != nil {
+			return err
+		}
+		rs.Stash = stash
+		if err := step(applyStepStash); err != nil {
+			return err
+		}
+	}
+
+	// A rebase starts from the worktree branch and replays it onto the base
+	start := rs.Base
+	if rs.Method == ApplyRebase {
+		start = rs.Branch
+	}
+	var err error
+	if rs.Scratch != "" {
+		err = WorktreeAdd(rs.RepoPath, rs.Scratch, rs.NewBranch, start)
+	} else {
+		err = CheckoutNewBranch(rs.RepoPath, rs.NewBranch, start)
+	}
+	if err != nil {
+		return err
+	}
+	if err := step(applyStepCheckout); err != nil {
+		return err
+	}
+
+	dir := rs.workDir()
+	switch rs.Method {
+	case ApplyStash:
+		err = StashApplyCommit(dir, rs.Stash)
+		if err == nil && rs.Scratch != "" {
+			err = commitScratch(rs)
+		}
+	case ApplyMerge:
+		err = Merge(dir, rs.Branch)
+	case ApplyRebase:
+		err = Rebase(dir, rs.Base)
+	case ApplySquash:
+		err = MergeSquash(dir, rs.Branch)
+		if err == nil {
+			err = Commit(dir, rs.Message)
+		}
+	case ApplyCherryPick:
+		err = CherryPick(dir, rs.Commits)
+	}
+	if err != nil {
+		if applyConflicted(rs) {
+			return &ApplyConflictError{RepoName: rs.Name, RepoPath: dir, Method: rs.Method}
+		}
+		return err
+	}
+	if err := step(applyStepChanges); err != nil {
+		return err
+	}
+
+	return finishApplyRepo(wsPath, state, rs)
+}
+
+// finishApplyRepo runs the final step of a repo whose changes are in place and marks it done.
+func finishApplyRepo(wsPath string, state *ApplyState, rs *ApplyRepoState) error {
+	// An isolated apply drops its temporary worktree. Otherwise methods that commit
+	// leave the original repo on the branch it was on, and stashed changes stay
+	// checked out on the new branch.
+	switch {
+	case rs.Scratch != "":
+		if err := WorktreeRemove(rs.RepoPath, rs.Scratch, false); err != nil {
+			return fmt.Errorf("%s: failed to remove the temporary worktree: %w", rs.Name, err)
+		}
+		rs.Steps = append(rs.Steps, applyStepReturn)
+	case rs.Method != ApplyStash:
+		if err := checkoutOrig(rs); err != nil {
+			return fmt.Errorf("%s: failed to return to %s: %w", rs.Name, rs.OrigBranch, err)
+		}
+		rs.Steps = append(rs.Steps, applyStepReturn)
+	}
+
+	rs.Status = applyDone
+	PrintSuccess("%s  applied via %s → %s (base: %s)", RepoNameStyle.Render(rs.Name), rs.Method, BranchNameStyle.Render(rs.NewBranch), rs.Base)
+	return saveApplyState(wsPath, state)
+}
+
+// applyConflicted reports whether a failed step left a conflict where the new branch is built.
+func applyConflicted(rs *ApplyRepoState) bool {
+	dir := rs.workDir()
+	switch rs.Method {
+	case ApplyMerge:
+		return IsMergeInProgress(dir)
+	case ApplyRebase:
+		return IsRebaseInProgress(dir)
+	case ApplyCherryPick:
+		return IsCherryPickInProgress(dir)
+	}
+	files, err := UnmergedFiles(dir)
+	return err == nil && len(files) > 0
+}
+
+// resolveApplyConflict completes the conflicted step of a repo once the user has
+// resolved it, or returns an *ApplyConflictError if conflicts remain.
+func resolveApplyConflict(rs *ApplyRepoState) error {
+	dir := rs.workDir()
+	conflict := &ApplyConflictError{RepoName: rs.Name, RepoPath: dir, Method: rs.Method}
+
+	var inProgress func(string) bool
+	var resume func(string) error
+	switch rs.Method {
+	case ApplyMerge:
+		inProgress, resume = IsMergeInProgress, MergeContinue
+	case ApplyRebase:
+		inProgress, resume = IsRebaseInProgress, RebaseContinue
+	case ApplyCherryPick:
+		inProgress, resume = IsCherryPickInProgress, CherryPickContinue
+	}
+	if inProgress != nil {
+		if !inProgress(dir) {
+			// The user finished the operation themselves
+			return nil
+		}
+		if err := resume(dir); err != nil {
+			if inProgress(dir) {
+				return conflict
+			}
+			return fmt.Errorf("%s: %w", rs.Name, err)
+		}
+		return nil
+	}
+
+	files, err := UnmergedFiles(dir)
+	if err != nil {
+		return fmt.Errorf("%s: %w", rs.Name, err)
+	}
+	if len(files) > 0 {
+		return conflict
+	}
+	if rs.Method == ApplySquash || rs.Scratch != "" {
+		status, err := StatusPorcelain(dir)
+		if err != nil {
+			return fmt.Errorf("%s: %w", rs.Name, err)
+		}
+		// An empty status means the user committed the changes themselves
+		if status != "" {
+			if err := commitScratch(rs); err != nil {
+				return fmt.Errorf("%s: %w", rs.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// commitScratch commits every change in the directory the new branch is built in.
+func commitScratch(rs *ApplyRepoState) error {
+	if err := StageAll(rs.workDir()); err != nil {
+		return err
+	}
+	return Commit(rs.workDir(), rs.Message)
+}
+
+// undoApplyRepo reverts the journaled steps of a repo in reverse order and clears them.
+func undoApplyRepo(rs *ApplyRepoState) error {
+	for len(rs.Steps) > 0 {
+		last := rs.Steps[len(rs.Steps)-1]
+
+		var err error
+		switch last {
+		case applyStepReturn:
+			// Nothing to undo: the original branch was restored
+		case applyStepChanges:
+			// Commits are undone by deleting the new branch; applied stash changes are discarded
+			if rs.Method == ApplyStash && rs.Scratch == "" {
+				err = discardStash(rs)
+			}
+		case applyStepCheckout:
+			if rs.Scratch != "" {
+				// Removing the temporary worktree also drops any conflicted operation in it.
+				// A finished repo has removed it already; only a stale registration is left to prune.
+				if _, serr := os.Stat(rs.Scratch); serr == nil {
+					err = WorktreeRemove(rs.RepoPath, rs.Scratch, true)
+				} else {
+					err = WorktreePrune(rs.RepoPath)
+				}
+				if err == nil {
+					err = BranchDelete(rs.RepoPath, rs.NewBranch, true)
+				}
+				break
+			}
+			// Abort a conflicted operation before leaving the new branch
+			switch {
+			case rs.Method == ApplyMerge && IsMergeInProgress(rs.RepoPath):
+				err = MergeAbort(rs.RepoPath)
+			case rs.Method == ApplyRebase && IsRebaseInProgress(rs.RepoPath):
+				err = RebaseAbort(rs.RepoPath)
+			case rs.Method == ApplyCherryPick && IsCherryPickInProgress(rs.RepoPath):
+				err = CherryPickAbort(rs.RepoPath)
+			case rs.Method == ApplySquash:
+				err = ResetHard(rs.RepoPath, "HEAD")
+			case rs.Method == ApplyStash:
+				err = discardStash(rs)
+			}
+			if err == nil {
+				err = checkoutOrig(rs)
+			}
+			if err == nil {
+				err = BranchDelete(rs.RepoPath, rs.NewBranch, true)
+			}
+		case applyStepStash:
+			err = StashApplyCommit(rs.Worktree, rs.Stash)
+			if err == nil {
+				err = StashDropCommit(rs.Worktree, rs.Stash)
+			}
+			if err == nil {
+				rs.Stash = ""
+			}
+		}
+		if err != nil {
+			return err
+		}
+		rs.Steps = slices.Delete(rs.Steps, len(rs.Steps)-1, len(rs.Steps))
+	}
+	return nil
+}
+
+// discardStash removes the stashed changes, including untracked files, from the original repo.
+func discardStash(rs *ApplyRepoState) error {
+	if err := ResetHard(rs.RepoPath, "HEAD"); err != nil {
+		return err
+	}
+	files, err := StashUntrackedFiles(rs.RepoPath, rs.Stash)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.Remove(filepath.Join(rs.RepoPath, f)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// SquashMessage returns the default commit message for squashing the commits of
+// branch that are not in base: the subject of a single commit, or a summary of all of them.
+func SquashMessage(repoPath, base, branch string) (string, error) {
+	entries, err := CommitLog(repoPath, base+".."+branch)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 {
+		return entries[0].Subject, nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Squash %d commits from %s\n", len(entries), branch)
+	if len(entries) > 0 {
+		b.WriteString("\n")
+	}
+	for _, e := range entries {
+		fmt.Fprintf(&b, "* %s\n", e.Subject)
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// checkoutOrig switches the original repo back to the branch (or detached HEAD) it was on.
+func checkoutOrig(rs *ApplyRepoState) error {
+	if rs.OrigBranch == "" || rs.OrigBranch == "HEAD" {
+		return CheckoutBranch(rs.RepoPath, rs.OrigHead)
+	}
+	return CheckoutBranch(rs.RepoPath, rs.OrigBranch)
+}
@@ -0,0 +1,383 @@
+package mangrove
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newApplyWorkspace creates a workspace "feat" over two repos and returns the
+// workspace path and the repos.
+func newApplyWorkspace(t *testing.T) (string, []Repo) {
+	t.Helper()
+	repos := []Repo{
+		{Name: "api", Path: newTestRepo(t)},
+		{Name: "web", Path: newTestRepo(t)},
+	}
+	cfg := &Config{BaseDir: t.TempDir()}
+	if err := CreateWorkspace(cfg, &Profile{Repos: repos}, "proj", "feat", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	return GetWorkspacePath(cfg, "proj", "feat"), repos
+}
+
+func applyTarget(wsPath string, repo Repo, method string) ApplyTarget {
+	return ApplyTarget{
+		RepoName:  repo.Name,
+		RepoPath:  repo.Path,
+		Worktree:  filepath.Join(wsPath, repo.Name),
+		Method:    method,
+		Branch:    "feat",
+		NewBranch: "apply/feat",
+		Base:      "main",
+	}
+}
+
+func TestStartApply(t *testing.T) {
+	wsPath, repos := newApplyWorkspace(t)
+	apiDir := filepath.Join(wsPath, "api")
+	webDir := filepath.Join(wsPath, "web")
+
+	if err := os.WriteFile(filepath.Join(apiDir, "README.md"), []byte("stashed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeAndCommit(t, webDir, "web.txt", "w\n", "web change")
+
+	state, err := StartApply(wsPath, []ApplyTarget{
+		applyTarget(wsPath, repos[0], ApplyStash),
+		applyTarget(wsPath, repos[1], ApplyMerge),
+	})
+	if err != nil {
+		t.Fatalf("StartApply() unexpected error: %v", err)
+	}
+	if len(state.Applied()) != 2 {
+		t.Errorf("Applied() = %+v, want both repos", state.Applied())
+	}
+
+	// stash: changes moved to the new branch in the original repo
+	if branch, _ := CurrentBranch(repos[0].Path); branch != "apply/feat" {
+		t.Errorf("api original branch = %q, want apply/feat", branch)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repos[0].Path, "README.md")); string(data) != "stashed\n" {
+		t.Errorf("api README.md = %q, want the stashed change", data)
+	}
+	if count, _ := StatusChangedCount(apiDir); count != 0 {
+		t.Errorf("api worktree has %d changes, want 0", count)
+	}
+	if out := runGit(t, repos[0].Path, "stash", "list"); out != "" {
+		t.Errorf("stash list = %q, want empty", out)
+	}
+
+	// merge: new branch has the commit, original branch is restored
+	if branch, _ := CurrentBranch(repos[1].Path); branch != "main" {
+		t.Errorf("web original branch = %q, want main", branch)
+	}
+	if out := runGit(t, repos[1].Path, "log", "-1", "--format=%s", "apply/feat"); out != "web change" {
+		t.Errorf("apply/feat tip = %q, want web change", out)
+	}
+
+	if s, _ := LoadApplyState(wsPath); s != nil {
+		t.Errorf("apply state should be removed, got %+v", s)
+	}
+}
+
+func TestApplyConflictAbort(t *testing.T) {
+	wsPath, repos := newApplyWorkspace(t)
+	apiDir := filepath.Join(wsPath, "api")
+	webDir := filepath.Join(wsPath, "web")
+
+	// api applies cleanly; web conflicts with a change on main
+	if err := os.WriteFile(filepath.Join(apiDir, "README.md"), []byte("stashed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeAndCommit(t, webDir, "README.md", "from feat\n", "feat readme")
+	writeAndCommit(t, repos[1].Path, "README.md", "from main\n", "main readme")
+	mainHead, _ := RevParse(repos[1].Path, "HEAD")
+
+	_, err := StartApply(wsPath, []ApplyTarget{
+		applyTarget(wsPath, repos[0], ApplyStash),
+		applyTarget(wsPath, repos[1], ApplyMerge),
+	})
+	var conflict *ApplyConflictError
+	if !errors.As(err, &conflict) || conflict.RepoName != "web" {
+		t.Fatalf("StartApply() error = %v, want a conflict in web", err)
+	}
+	if !IsMergeInProgress(repos[1].Path) {
+		t.Error("the conflict should be left in place in the original repo")
+	}
+	if _, err := StartApply(wsPath, nil); err == nil {
+		t.Error("StartApply() while an apply is in progress should fail")
+	}
+
+	if err := AbortApply(wsPath); err != nil {
+		t.Fatalf("AbortApply() unexpected error: %v", err)
+	}
+
+	for _, repo := range repos {
+		if branch, _ := CurrentBranch(repo.Path); branch != "main" {
+			t.Errorf("%s original branch = %q, want main", repo.Name, branch)
+		}
+		if RefExists(repo.Path, "refs/heads/apply/feat") {
+			t.Errorf("%s: apply/feat should be deleted", repo.Name)
+		}
+		if count, _ := StatusChangedCount(repo.Path); count != 0 {
+			t.Errorf("%s original repo has %d changes, want 0", repo.Name, count)
+		}
+	}
+	if IsMergeInProgress(repos[1].Path) {
+		t.Error("web merge should be aborted")
+	}
+	if head, _ := RevParse(repos[1].Path, "HEAD"); head != mainHead {
+		t.Errorf("web HEAD = %s, want %s", head, mainHead)
+	}
+	if data, _ := os.ReadFile(filepath.Join(apiDir, "README.md")); string(data) != "stashed\n" {
+		t.Errorf("api worktree README.md = %q, want the change back", data)
+	}
+	if out := runGit(t, repos[0].Path, "stash", "list"); out != "" {
+		t.Errorf("stash list = %q, want empty", out)
+	}
+	if s, _ := LoadApplyState(wsPath); s != nil {
+		t.Errorf("apply state should be removed, got %+v", s)
+	}
+	if err := AbortApply(wsPath); !errors.Is(err, ErrNoApplyInProgress) {
+		t.Errorf("second AbortApply() error = %v, want ErrNoApplyInProgress", err)
+	}
+}
+
+func TestApplyConflictContinue(t *testing.T) {
+	wsPath, repos := newApplyWorkspace(t)
+	apiDir := filepath.Join(wsPath, "api")
+
+	// The stashed change conflicts with a change on main
+	writeAndCommit(t, repos[0].Path, "README.md", "from main\n", "main readme")
+	if err := os.WriteFile(filepath.Join(apiDir, "README.md"), []byte("from feat\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := StartApply(wsPath, []ApplyTarget{applyTarget(wsPath, repos[0], ApplyStash)})
+	var conflict *ApplyConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("StartApply() error = %v, want a conflict", err)
+	}
+
+	// Still unresolved
+	if _, err := ContinueApply(wsPath); !errors.As(err, &conflict) {
+		t.Fatalf("ContinueApply() error = %v, want the conflict again", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(repos[0].Path, "README.md"), []byte("resolved\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repos[0].Path, "add", "README.md")
+
+	state, err := ContinueApply(wsPath)
+	if err != nil {
+		t.Fatalf("ContinueApply() unexpected error: %v", err)
+	}
+	if len(state.Applied()) != 1 {
+		t.Errorf("Applied() = %+v, want api", state.Applied())
+	}
+	if branch, _ := CurrentBranch(repos[0].Path); branch != "apply/feat" {
+		t.Errorf("api original branch = %q, want apply/feat", branch)
+	}
+	if out := runGit(t, repos[0].Path, "stash", "list"); out != "" {
+		t.Errorf("stash list = %q, want empty", out)
+	}
+	if s, _ := LoadApplyState(wsPath); s != nil {
+		t.Errorf("apply state should be removed, got %+v", s)
+	}
+}
+
+func TestApplyCommitMethods(t *testing.T) {
+	tests := []struct {
+		method      string
+		wantSubject []string
+	}{
+		{ApplyRebase, []string{"second", "first", "main change"}},
+		{ApplySquash, []string{"squashed", "main change"}},
+		{ApplyCherryPick, []string{"second", "main change"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			wsPath, repos := newApplyWorkspace(t)
+			apiDir := filepath.Join(wsPath, "api")
+
+			writeAndCommit(t, apiDir, "first.txt", "1\n", "first")
+			writeAndCommit(t, apiDir, "second.txt", "2\n", "second")
+			second, _ := RevParse(apiDir, "HEAD")
+			writeAndCommit(t, repos[0].Path, "main.txt", "m\n", "main change")
+
+			target := applyTarget(wsPath, repos[0], tt.method)
+			target.Message = "squashed"
+			target.Commits = []string{second}
+			if _, err := StartApply(wsPath, []ApplyTarget{target}); err != nil {
+				t.Fatalf("StartApply() unexpected error: %v", err)
+			}
+
+			if branch, _ := CurrentBranch(repos[0].Path); branch != "main" {
+				t.Errorf("original branch = %q, want main", branch)
+			}
+			out := runGit(t, repos[0].Path, "log", "--format=%s", "-n", strconv.Itoa(len(tt.wantSubject)), "apply/feat")
+			if got := strings.Split(out, "\n"); !slices.Equal(got, tt.wantSubject) {
+				t.Errorf("apply/feat log = %q, want %q", got, tt.wantSubject)
+			}
+		})
+	}
+}
+
+func TestApplyRebaseConflictAbort(t *testing.T) {
+	wsPath, repos := newApplyWorkspace(t)
+	writeAndCommit(t, filepath.Join(wsPath, "api"), "README.md", "from feat\n", "feat readme")
+	writeAndCommit(t, repos[0].Path, "README.md", "from main\n", "main readme")
+
+	_, err := StartApply(wsPath, []ApplyTarget{applyTarget(wsPath, repos[0], ApplyRebase)})
+	var conflict *ApplyConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("StartApply() error = %v, want a conflict", err)
+	}
+	if !IsRebaseInProgress(repos[0].Path) {
+		t.Fatal("the rebase should be left in progress in the original repo")
+	}
+
+	if err := AbortApply(wsPath); err != nil {
+		t.Fatalf("AbortApply() unexpected error: %v", err)
+	}
+	if IsRebaseInProgress(repos[0].Path) {
+		t.Error("rebase should be aborted")
+	}
+	if branch, _ := CurrentBranch(repos[0].Path); branch != "main" {
+		t.Errorf("original branch = %q, want main", branch)
+	}
+	if RefExists(repos[0].Path, "refs/heads/apply/feat") {
+		t.Error("apply/feat should be deleted")
+	}
+}
+
+func TestSquashMessage(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "checkout", "-q", "-b", "feat")
+
+	writeAndCommit(t, dir, "a.txt", "a\n", "add a")
+	if got, _ := SquashMessage(dir, "main", "feat"); got != "add a" {
+		t.Errorf("SquashMessage() = %q, want the single subject", got)
+	}
+
+	writeAndCommit(t, dir, "b.txt", "b\n", "add b")
+	want := "Squash 2 commits from feat\n\n* add a\n* add b"
+	if got, _ := SquashMessage(dir, "main", "feat"); got != want {
+		t.Errorf("SquashMessage() = %q, want %q", got, want)
+	}
+}
+
+func TestApplyIsolated(t *testing.T) {
+	wsPath, repos := newApplyWorkspace(t)
+	apiDir := filepath.Join(wsPath, "api")
+	webDir := filepath.Join(wsPath, "web")
+
+	// The original repos are dirty and on another branch; neither may change
+	for _, repo := range repos {
+		runGit(t, repo.Path, "checkout", "-q", "-b", "local")
+		if err := os.WriteFile(filepath.Join(repo.Path, "wip.txt"), []byte("wip\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(apiDir, "new.txt"), []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeAndCommit(t, webDir, "web.txt", "w\n", "web change")
+
+	stash := applyTarget(wsPath, repos[0], ApplyStash)
+	stash.Isolated = true
+	stash.Message = "apply changes"
+	merge := applyTarget(wsPath, repos[1], ApplyMerge)
+	merge.Isolated = true
+	if _, err := StartApply(wsPath, []ApplyTarget{stash, merge}); err != nil {
+		t.Fatalf("StartApply() unexpected error: %v", err)
+	}
+
+	for _, repo := range repos {
+		if branch, _ := CurrentBranch(repo.Path); branch != "local" {
+			t.Errorf("%s original branch = %q, want local", repo.Name, branch)
+		}
+		if out := runGit(t, repo.Path, "status", "--porcelain"); out != "?? wip.txt" {
+			t.Errorf("%s original status = %q, want only wip.txt", repo.Name, out)
+		}
+	}
+	if out := runGit(t, repos[0].Path, "show", "--format=%s", "--name-only", "apply/feat"); out != "apply changes\n\nnew.txt" {
+		t.Errorf("api apply/feat tip = %q, want the stashed file committed", out)
+	}
+	if out := runGit(t, repos[1].Path, "log", "-1", "--format=%s", "apply/feat"); out != "web change" {
+		t.Errorf("web apply/feat tip = %q, want web change", out)
+	}
+	if _, err := os.Stat(filepath.Join(wsPath, applyScratchDir)); !os.IsNotExist(err) {
+		t.Errorf("temporary worktrees should be removed, stat error = %v", err)
+	}
+}
+
+func TestApplyIsolatedConflictAbort(t *testing.T) {
+	wsPath, repos := newApplyWorkspace(t)
+	writeAndCommit(t, filepath.Join(wsPath, "api"), "README.md", "from feat\n", "feat readme")
+	writeAndCommit(t, repos[0].Path, "README.md", "from main\n", "main readme")
+
+	target := applyTarget(wsPath, repos[0], ApplyRebase)
+	target.Isolated = true
+	_, err := StartApply(wsPath, []ApplyTarget{target})
+	var conflict *ApplyConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("StartApply() error = %v, want a conflict", err)
+	}
+	if want := filepath.Join(wsPath, applyScratchDir, "api"); conflict.RepoPath != want {
+		t.Errorf("conflict path = %q, want %q", conflict.RepoPath, want)
+	}
+	if IsRebaseInProgress(repos[0].Path) {
+		t.Error("the original repo should not be rebasing")
+	}
+
+	if err := AbortApply(wsPath); err != nil {
+		t.Fatalf("AbortApply() unexpected error: %v", err)
+	}
+	if RefExists(repos[0].Path, "refs/heads/apply/feat") {
+		t.Error("apply/feat should be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(wsPath, applyScratchDir)); !os.IsNotExist(err) {
+		t.Errorf("temporary worktrees should be removed, stat error = %v", err)
+	}
+}
+
+func TestApplyIsolatedAbortAfterFinishedRepo(t *testing.T) {
+	wsPath, repos := newApplyWorkspace(t)
+	// api applies cleanly; web conflicts
+	writeAndCommit(t, filepath.Join(wsPath, "api"), "api.txt", "a\n", "api change")
+	writeAndCommit(t, filepath.Join(wsPath, "web"), "README.md", "from feat\n", "feat readme")
+	writeAndCommit(t, repos[1].Path, "README.md", "from main\n", "main readme")
+
+	targets := []ApplyTarget{
+		applyTarget(wsPath, repos[0], ApplyMerge),
+		applyTarget(wsPath, repos[1], ApplyMerge),
+	}
+	for i := range targets {
+		targets[i].Isolated = true
+	}
+	_, err := StartApply(wsPath, targets)
+	var conflict *ApplyConflictError
+	if !errors.As(err, &conflict) || conflict.RepoName != "web" {
+		t.Fatalf("StartApply() error = %v, want a conflict in web", err)
+	}
+
+	if err := AbortApply(wsPath); err != nil {
+		t.Fatalf("AbortApply() unexpected error: %v", err)
+	}
+	for _, repo := range repos {
+		if RefExists(repo.Path, "refs/heads/apply/feat") {
+			t.Errorf("%s: apply/feat should be deleted", repo.Name)
+		}
+	}
+	if s, _ := LoadApplyState(wsPath); s != nil {
+		t.Errorf("apply state should be removed, got %+v", s)
+	}
+}
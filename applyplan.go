@@ -0,0 +1,157 @@
+package mangrove
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ApplyPlan is a preview of what applying one repo would do.
+type ApplyPlan struct {
+	RepoResult `yaml:",inline"`
+	Method     string `json:"method"             yaml:"method"`
+	Base       string `json:"base"               yaml:"base"`
+	NewBranch  string `json:"new_branch"         yaml:"new_branch"`
+	Isolated   bool   `json:"isolated,omitempty" yaml:"isolated,omitempty"`
+	// Dirty reports uncommitted changes in the original repo, which block an apply
+	// that is not isolated.
+	Dirty bool `json:"dirty,omitempty" yaml:"dirty,omitempty"`
+	// Commits are the commits that would move, oldest first. Empty for ApplyStash.
+	Commits []LogEntry `json:"commits,omitempty" yaml:"commits,omitempty"`
+	// Files are the files that would change, as "<status> <path>".
+	Files []string `json:"files,omitempty" yaml:"files,omitempty"`
+	// Conflicts are the files expected to conflict, as predicted by git merge-tree.
+	Conflicts []string `json:"conflicts,omitempty" yaml:"conflicts,omitempty"`
+	// ConflictCheck is set when conflicts could not be predicted.
+	ConflictCheck string `json:"conflict_check,omitempty" yaml:"conflict_check,omitempty"`
+}
+
+// Blocked reports whether the apply would refuse this repo.
+func (p *ApplyPlan) Blocked() bool {
+	return p.Dirty && !p.Isolated
+}
+
+// PlanApply previews each target without changing any repo, worktree or ref.
+// Conflicts are predicted with git merge-tree: a merge, squash or rebase of the whole
+// branch onto the base, the stashed changes onto the base, or each cherry-picked
+// commit onto the base on its own.
+func PlanApply(targets []ApplyTarget) []ApplyPlan {
+	plans := make([]ApplyPlan, len(targets))
+	for i, t := range targets {
+		plans[i] = planApplyRepo(t)
+		plans[i].setError()
+	}
+	return plans
+}
+
+// planApplyRepo previews a single target.
+func planApplyRepo(t ApplyTarget) ApplyPlan {
+	plan := ApplyPlan{
+		RepoResult: RepoResult{Name: t.RepoName},
+		Method:     t.Method,
+		Base:       t.Base,
+		NewBranch:  t.NewBranch,
+		Isolated:   t.Isolated,
+	}
+
+	status, err := StatusPorcelain(t.RepoPath)
+	if err != nil {
+		plan.Err = err
+		return plan
+	}
+	plan.Dirty = status != ""
+
+	var conflicts []string
+	var conflictErr error
+	switch t.Method {
+	case ApplyStash:
+		files, err := ChangedFiles(t.Worktree)
+		if err != nil {
+			plan.Err = err
+			return plan
+		}
+		for _, f := range files {
+			plan.Files = append(plan.Files, fmt.Sprintf("%s %s", trimStatus(f.Status), f.Path))
+		}
+
+		// The stash commit is an unreferenced object; untracked files cannot conflict
+		stash, err := StashCreate(t.Worktree)
+		if err != nil {
+			plan.Err = err
+			return plan
+		}
+		if stash != "" {
+			conflicts, conflictErr = MergeTree(t.RepoPath, stash+"^1", t.Base, stash)
+		}
+
+	case ApplyCherryPick:
+		for _, c := range t.Commits {
+			entries, err := CommitLog(t.RepoPath, c+"^!")
+			if err != nil {
+				plan.Err = err
+				return plan
+			}
+			plan.Commits = append(plan.Commits, entries...)
+
+			files, err := DiffNameStatus(t.RepoPath, c+"^", c)
+			if err != nil {
+				plan.Err = err
+				return plan
+			}
+			plan.Files = mergeFileLists(plan.Files, files)
+
+			if conflictErr == nil {
+				var found []string
+				found, conflictErr = MergeTree(t.RepoPath, c+"^", t.Base, c)
+				conflicts = mergeFileLists(conflicts, found)
+			}
+		}
+
+	default:
+		plan.Commits, err = CommitLog(t.RepoPath, t.Base+".."+t.Branch)
+		if err != nil {
+			plan.Err = err
+			return plan
+		}
+		mergeBase, err := MergeBase(t.RepoPath, t.Base, t.Branch)
+		if err != nil {
+			plan.Err = err
+			return plan
+		}
+		plan.Files, err = DiffNameStatus(t.RepoPath, mergeBase, t.Branch)
+		if err != nil {
+			plan.Err = err
+			return plan
+		}
+		conflicts, conflictErr = MergeTree(t.RepoPath, "", t.Base, t.Branch)
+	}
+
+	if conflictErr != nil {
+		plan.ConflictCheck = conflictErr.Error()
+	} else {
+		plan.Conflicts = conflicts
+	}
+	return plan
+}
+
+// trimStatus turns a two-letter porcelain status into a single code, e.g. " M" into "M" and "??" into "A".
+func trimStatus(status string) string {
+	if status == "??" {
+		return "A"
+	}
+	for _, c := range status {
+		if c != ' ' {
+			return string(c)
+		}
+	}
+	return status
+}
+
+// mergeFileLists appends the entries of b that are not already in a.
+func mergeFileLists(a, b []string) []string {
+	for _, s := range b {
+		if !slices.Contains(a, s) {
+			a = append(a, s)
+		}
+	}
+	return a
+}
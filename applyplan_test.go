@@ -0,0 +1,97 @@
+package mangrove
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPlanApply(t *testing.T) {
+	wsPath, repos := newApplyWorkspace(t)
+	apiDir := filepath.Join(wsPath, "api")
+	webDir := filepath.Join(wsPath, "web")
+
+	// api: clean merge; web: conflicts with main and the original repo is dirty
+	writeAndCommit(t, apiDir, "api.txt", "a\n", "api change")
+	writeAndCommit(t, webDir, "README.md", "from feat\n", "feat readme")
+	writeAndCommit(t, repos[1].Path, "README.md", "from main\n", "main readme")
+	if err := os.WriteFile(filepath.Join(repos[1].Path, "wip.txt"), []byte("wip\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	apiHead, _ := RevParse(repos[0].Path, "HEAD")
+
+	plans := PlanApply([]ApplyTarget{
+		applyTarget(wsPath, repos[0], ApplyMerge),
+		applyTarget(wsPath, repos[1], ApplyMerge),
+	})
+
+	api := plans[0]
+	if api.Err != nil || api.ConflictCheck != "" {
+		t.Fatalf("api plan failed: %v %s", api.Err, api.ConflictCheck)
+	}
+	if len(api.Commits) != 1 || api.Commits[0].Subject != "api change" {
+		t.Errorf("api commits = %+v, want api change", api.Commits)
+	}
+	if !slices.Equal(api.Files, []string{"A api.txt"}) {
+		t.Errorf("api files = %q, want [A api.txt]", api.Files)
+	}
+	if api.Dirty || len(api.Conflicts) != 0 || api.Blocked() {
+		t.Errorf("api plan = %+v, want clean", api)
+	}
+
+	web := plans[1]
+	if web.Err != nil || web.ConflictCheck != "" {
+		t.Fatalf("web plan failed: %v %s", web.Err, web.ConflictCheck)
+	}
+	if !slices.Equal(web.Conflicts, []string{"README.md"}) {
+		t.Errorf("web conflicts = %q, want [README.md]", web.Conflicts)
+	}
+	if !web.Dirty || !web.Blocked() {
+		t.Errorf("web plan = %+v, want dirty and blocked", web)
+	}
+
+	// Nothing is changed
+	if head, _ := RevParse(repos[0].Path, "HEAD"); head != apiHead {
+		t.Errorf("api HEAD = %s, want %s", head, apiHead)
+	}
+	for _, repo := range repos {
+		if RefExists(repo.Path, "refs/heads/apply/feat") {
+			t.Errorf("%s: apply/feat should not be created", repo.Name)
+		}
+	}
+	if s, _ := LoadApplyState(wsPath); s != nil {
+		t.Errorf("no apply state should be written, got %+v", s)
+	}
+}
+
+func TestPlanApplyStash(t *testing.T) {
+	wsPath, repos := newApplyWorkspace(t)
+	apiDir := filepath.Join(wsPath, "api")
+
+	if err := os.WriteFile(filepath.Join(apiDir, "README.md"), []byte("changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(apiDir, "new.txt"), []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plans := PlanApply([]ApplyTarget{applyTarget(wsPath, repos[0], ApplyStash)})
+	if plans[0].Err != nil {
+		t.Fatalf("PlanApply() unexpected error: %v", plans[0].Err)
+	}
+	if want := []string{"M README.md", "A new.txt"}; !slices.Equal(plans[0].Files, want) {
+		t.Errorf("files = %q, want %q", plans[0].Files, want)
+	}
+	if len(plans[0].Commits) != 0 {
+		t.Errorf("commits = %+v, want none", plans[0].Commits)
+	}
+
+	// The worktree changes and the stash list are untouched
+	if count, _ := StatusChangedCount(apiDir); count != 2 {
+		t.Errorf("worktree has %d changes, want 2", count)
+	}
+	if out := runGit(t, apiDir, "stash", "list"); out != "" {
+		t.Errorf("stash list = %q, want empty", out)
+	}
+}
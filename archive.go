@@ -0,0 +1,425 @@
+package mangrove
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// archiveRecordFile is the name of the record file in each archive directory.
+const archiveRecordFile = "archive.yaml"
+
+// ArchivedRepo records the state of one repo worktree at archive time.
+// Patch and Bundle are file names in the archive directory, empty when there
+// were no uncommitted changes or no commits ahead of the base.
+type ArchivedRepo struct {
+	Name   string `yaml:"name"             json:"name"`
+	Path   string `yaml:"path"             json:"path"`
+	Branch string `yaml:"branch"           json:"branch"`
+	Head   string `yaml:"head"             json:"head"`
+	Base   string `yaml:"base"             json:"base"`
+	Patch  string `yaml:"patch,omitempty"  json:"patch,omitempty"`
+	Bundle string `yaml:"bundle,omitempty" json:"bundle,omitempty"`
+}
+
+// ArchiveRecord describes an archived workspace.
+type ArchiveRecord struct {
+	Profile    string             `yaml:"profile"     json:"profile"`
+	Name       string             `yaml:"name"        json:"name"`
+	ArchivedAt time.Time          `yaml:"archived_at" json:"archived_at"`
+	Repos      []ArchivedRepo     `yaml:"repos"       json:"repos"`
+	Metadata   *WorkspaceMetadata `yaml:"metadata"    json:"metadata"`
+}
+
+// Label formats the record for fzf selection, in the same
+// "profile/workspace     ..." format as WorkspaceLabels.
+func (r *ArchiveRecord) Label() string {
+	parts := []string{fmt.Sprintf("%s/%s", r.Profile, r.Name)}
+	for _, ar := range r.Repos {
+		parts = append(parts, fmt.Sprintf("[%s: %s]", ar.Name, ar.Branch))
+	}
+	parts = append(parts, "archived "+r.ArchivedAt.Local().Format("2006-01-02 15:04"))
+	return strings.Join(parts, "     ")
+}
+
+// ArchiveDir returns the directory an archived workspace is stored in,
+// archives/<profile>/<name> in ConfigDir (~/.config/mgv by default).
+func ArchiveDir(profileName, name string) (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "archives", profileName, name), nil
+}
+
+// LoadArchive reads the record of an archived workspace.
+func LoadArchive(profileName, name string) (*ArchiveRecord, error) {
+	dir, err := ArchiveDir(profileName, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, archiveRecordFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no archived workspace %q in profile %q", name, profileName)
+		}
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	var record ArchiveRecord
+	if err := yaml.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse archive: %w", err)
+	}
+	return &record, nil
+}
+
+// ListArchives returns the archived workspaces sorted by profile and name.
+// If profileName is empty, all profiles are listed.
+func ListArchives(profileName string) ([]ArchiveRecord, error) {
+	root, err := ArchiveDir("", "")
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := []string{profileName}
+	if profileName == "" {
+		entries, err := os.ReadDir(root)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read archives: %w", err)
+		}
+		profiles = nil
+		for _, e := range entries {
+			if e.IsDir() {
+				profiles = append(profiles, e.Name())
+			}
+		}
+	}
+
+	var records []ArchiveRecord
+	for _, pName := range profiles {
+		entries, err := os.ReadDir(filepath.Join(root, pName))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read archives: %w", err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			record, err := LoadArchive(pName, e.Name())
+			if err != nil {
+				PrintWarning("%s/%s: %v", pName, e.Name(), err)
+				continue
+			}
+			records = append(records, *record)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Profile != records[j].Profile {
+			return records[i].Profile < records[j].Profile
+		}
+		return records[i].Name < records[j].Name
+	})
+	return records, nil
+}
+
+// ArchiveWorkspace saves the branch, HEAD and uncommitted changes of every repo in
+// a workspace under the config directory, then removes the worktrees and the
+// workspace directory. Branches are kept; commits ahead of the base are also
+// saved to a bundle so the workspace can be restored even if a branch is deleted.
+// Hooks do not run.
+func ArchiveWorkspace(cfg *Config, profile *Profile, profileName, name string) error {
+	wsPath := GetWorkspacePath(cfg, profileName, name)
+	if _, err := os.Stat(wsPath); os.IsNotExist(err) {
+		return fmt.Errorf("workspace %q not found at %s", name, wsPath)
+	}
+
+	if err := CheckInProgress(wsPath, name); err != nil {
+		return err
+	}
+
+	dir, err := ArchiveDir(profileName, name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("workspace %q is already archived at %s", name, dir)
+	}
+
+	meta, err := LoadWorkspaceMetadata(wsPath)
+	if err != nil {
+		return err
+	}
+	if meta == nil {
+		if meta, err = newWorkspaceMetadata(profile, profileName, name, wsPath); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	record := &ArchiveRecord{
+		Profile:    profileName,
+		Name:       name,
+		ArchivedAt: time.Now().UTC().Truncate(time.Second),
+		Metadata:   meta,
+	}
+
+	wsProfile := *profile
+	wsProfile.Repos = WorkspaceRepos(meta, profile)
+	srcs, err := workspaceSources(meta, &wsProfile, profileName, name)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "\nArchiving workspace: %s/%s\n", profileName, name)
+
+	for _, repo := range wsProfile.Repos {
+		ar, err := archiveRepo(dir, wsPath, meta, &repo)
+		if err != nil {
+			_ = os.RemoveAll(dir)
+			return fmt.Errorf("%s: %w", repo.Name, err)
+		}
+		record.Repos = append(record.Repos, *ar)
+	}
+
+	data, err := yaml.Marshal(record)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return fmt.Errorf("failed to marshal archive: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, archiveRecordFile), data, 0o644); err != nil {
+		_ = os.RemoveAll(dir)
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	// Everything is saved; the worktrees can go
+	if failed := removeWorktrees(&wsProfile, wsPath, srcs, false, true); len(failed) > 0 {
+		return keepArchivedWorkspace(dir, wsPath, record, failed)
+	}
+	if err := os.RemoveAll(wsPath); err != nil {
+		return fmt.Errorf("failed to remove workspace directory: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nWorkspace archived: %s\n", dir)
+	return nil
+}
+
+// keepArchivedWorkspace puts back the worktrees that were already removed when
+// others could not be, so the workspace stays as it was, then drops the archive.
+// If a worktree cannot be put back, the archive is kept since it holds its changes.
+func keepArchivedWorkspace(dir, wsPath string, record *ArchiveRecord, failed []string) error {
+	err := fmt.Errorf("failed to remove the worktrees of %s; the workspace is kept", strings.Join(failed, ", "))
+
+	var errs []error
+	for _, ar := range record.Repos {
+		wtDir := filepath.Join(wsPath, ar.Name)
+		if _, serr := os.Stat(wtDir); serr == nil {
+			continue
+		}
+		if rerr := restoreWorktree(dir, wtDir, &ar); rerr != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ar.Name, rerr))
+		}
+	}
+	if rerr := errors.Join(errs...); rerr != nil {
+		return fmt.Errorf("%w\nfailed to put back removed worktrees: %v\nthe archive is kept at %s", err, rerr, dir)
+	}
+
+	if rerr := os.RemoveAll(dir); rerr != nil {
+		PrintWarning("Failed to remove archive: %v", rerr)
+	}
+	return err
+}
+
+// archiveRepo saves one repo worktree into the archive directory.
+func archiveRepo(dir, wsPath string, meta *WorkspaceMetadata, repo *Repo) (*ArchivedRepo, error) {
+	wtDir := filepath.Join(wsPath, repo.Name)
+
+	branch, err := CurrentBranch(wtDir)
+	if err != nil {
+		return nil, err
+	}
+	if branch == "HEAD" || branch == "" {
+		return nil, fmt.Errorf("worktree is on a detached HEAD; check out a branch before archiving")
+	}
+	head, err := RevParse(wtDir, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	ar := &ArchivedRepo{
+		Name:   repo.Name,
+		Path:   repo.Path,
+		Branch: branch,
+		Head:   head,
+		Base:   meta.RepoBase(repo),
+	}
+
+	patch, err := DiffUncommitted(wtDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(patch) > 0 {
+		ar.Patch = repo.Name + ".patch"
+		if err := os.WriteFile(filepath.Join(dir, ar.Patch), patch, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write patch: %w", err)
+		}
+	}
+
+	if ahead, _, err := AheadBehind(repo.Path, ar.Base, head); err == nil && ahead > 0 {
+		ar.Bundle = repo.Name + ".bundle"
+		if err := BundleCreate(repo.Path, filepath.Join(dir, ar.Bundle), branch, ar.Base); err != nil {
+			return nil, err
+		}
+	}
+
+	detail := fmt.Sprintf("%s @ %s", branch, shortHash(head))
+	if ar.Patch != "" {
+		detail += ", uncommitted changes saved"
+	}
+	PrintSuccess("%s  %s", RepoNameStyle.Render(repo.Name), detail)
+	return ar, nil
+}
+
+// RestoreWorkspace recreates an archived workspace: each worktree is checked out on
+// its archived branch at the archived HEAD, uncommitted changes are re-applied and
+// the metadata is restored. The archive is deleted once the workspace is back.
+// A branch that moved since it was archived is an error unless force is set, in
+// which case the worktree is checked out at the branch's current tip.
+// If any step fails, the branches, worktrees and directory created so far are
+// removed again and the archive is kept.
+func RestoreWorkspace(cfg *Config, profileName, name string, force bool) (err error) {
+	record, err := LoadArchive(profileName, name)
+	if err != nil {
+		return err
+	}
+	dir, err := ArchiveDir(profileName, name)
+	if err != nil {
+		return err
+	}
+
+	wsPath := GetWorkspacePath(cfg, profileName, name)
+	if _, err := os.Stat(wsPath); err == nil {
+		return fmt.Errorf("workspace %q already exists at %s", name, wsPath)
+	}
+
+	// Every step registers how to undo it; on failure they run in reverse
+	var undo []func() error
+	defer func() {
+		if err == nil {
+			return
+		}
+		PrintWarning("Rolling back restore...")
+		var errs []error
+		for i := len(undo) - 1; i >= 0; i-- {
+			if uerr := undo[i](); uerr != nil {
+				errs = append(errs, uerr)
+			}
+		}
+		if rerr := errors.Join(errs...); rerr != nil {
+			err = fmt.Errorf("%w\nrollback failed: %v", err, rerr)
+		}
+		err = fmt.Errorf("%w\nthe archive is kept at %s", err, dir)
+	}()
+
+	// Bring every branch back to its archived HEAD before touching the workspace
+	for _, ar := range record.Repos {
+		created, err := restoreBranch(dir, &ar, force)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ar.Name, err)
+		}
+		if created {
+			undo = append(undo, func() error {
+				return BranchDelete(ar.Path, ar.Branch, true)
+			})
+		}
+	}
+
+	if err := os.MkdirAll(wsPath, 0o755); err != nil {
+		return fmt.Errorf("failed to create workspace directory: %w", err)
+	}
+	undo = append(undo, func() error {
+		return os.RemoveAll(wsPath)
+	})
+
+	fmt.Fprintf(os.Stderr, "\nRestoring workspace: %s/%s\n", profileName, name)
+
+	for _, ar := range record.Repos {
+		wtDir := filepath.Join(wsPath, ar.Name)
+		if err := restoreWorktree(dir, wtDir, &ar); err != nil {
+			return fmt.Errorf("%s: %w", ar.Name, err)
+		}
+		undo = append(undo, func() error {
+			return WorktreeRemove(ar.Path, wtDir, true)
+		})
+		PrintSuccess("%s  %s @ %s", RepoNameStyle.Render(ar.Name), BranchNameStyle.Render(ar.Branch), shortHash(ar.Head))
+	}
+
+	if record.Metadata != nil {
+		if err := SaveWorkspaceMetadata(wsPath, record.Metadata); err != nil {
+			return err
+		}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		PrintWarning("Failed to remove archive: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nWorkspace ready: %s\n", wsPath)
+	return nil
+}
+
+// restoreBranch makes sure the archived branch exists at the archived HEAD, recreating
+// it from the commit or the bundle if it was deleted. It reports whether the branch
+// was recreated. A branch that moved is kept as it is only when force is set.
+func restoreBranch(dir string, ar *ArchivedRepo, force bool) (bool, error) {
+	if RefExists(ar.Path, "refs/heads/"+ar.Branch) {
+		tip, err := RevParse(ar.Path, ar.Branch)
+		if err != nil {
+			return false, err
+		}
+		if tip != ar.Head {
+			if !force {
+				return false, fmt.Errorf("branch %s moved since it was archived (%s → %s). Use --force to restore onto %s", ar.Branch, shortHash(ar.Head), shortHash(tip), shortHash(tip))
+			}
+			PrintWarning("%s: branch %s moved since it was archived (%s → %s)", ar.Name, ar.Branch, shortHash(ar.Head), shortHash(tip))
+		}
+		return false, nil
+	}
+
+	if _, err := RevParse(ar.Path, ar.Head); err == nil {
+		return true, BranchCreate(ar.Path, ar.Branch, ar.Head)
+	}
+	if ar.Bundle != "" {
+		return true, BundleFetch(ar.Path, filepath.Join(dir, ar.Bundle), ar.Branch)
+	}
+	return false, fmt.Errorf("branch %s and commit %s no longer exist", ar.Branch, shortHash(ar.Head))
+}
+
+// restoreWorktree checks out the archived branch at wtDir and re-applies the
+// archived uncommitted changes. A worktree whose changes do not apply is removed.
+func restoreWorktree(dir, wtDir string, ar *ArchivedRepo) error {
+	if err := WorktreeAddExisting(ar.Path, wtDir, ar.Branch); err != nil {
+		return err
+	}
+	if ar.Patch == "" {
+		return nil
+	}
+	if err := ApplyPatch(wtDir, filepath.Join(dir, ar.Patch)); err != nil {
+		_ = WorktreeRemove(ar.Path, wtDir, true)
+		return err
+	}
+	return nil
+}
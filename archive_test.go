@@ -0,0 +1,252 @@
+package mangrove
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveAndRestoreWorkspace(t *testing.T) {
+	repos := []Repo{
+		{Name: "api", Path: newTestRepo(t)},
+		{Name: "web", Path: newTestRepo(t)},
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "feat", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	wsPath := GetWorkspacePath(cfg, "proj", "feat")
+	apiDir := filepath.Join(wsPath, "api")
+
+	// A commit, an uncommitted change and an untracked file in api
+	writeAndCommit(t, apiDir, "feature.txt", "v1\n", "add feature")
+	if err := os.WriteFile(filepath.Join(apiDir, "feature.txt"), []byte("v2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(apiDir, "scratch.txt"), []byte("wip\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	head, _ := RevParse(apiDir, "HEAD")
+
+	if err := ArchiveWorkspace(cfg, profile, "proj", "feat"); err != nil {
+		t.Fatalf("ArchiveWorkspace() unexpected error: %v", err)
+	}
+	if _, err := os.Stat(wsPath); !os.IsNotExist(err) {
+		t.Errorf("workspace directory should be removed, stat err = %v", err)
+	}
+	if !RefExists(repos[0].Path, "refs/heads/feat") {
+		t.Error("branch feat should be kept after archiving")
+	}
+
+	records, err := ListArchives("proj")
+	if err != nil {
+		t.Fatalf("ListArchives() unexpected error: %v", err)
+	}
+	if len(records) != 1 || records[0].Name != "feat" {
+		t.Fatalf("ListArchives() = %+v, want one archive named feat", records)
+	}
+	api := records[0].Repos[0]
+	if api.Name != "api" || api.Head != head || api.Patch == "" || api.Bundle == "" {
+		t.Errorf("archived api = %+v, want head %s with patch and bundle", api, head)
+	}
+	if web := records[0].Repos[1]; web.Patch != "" || web.Bundle != "" {
+		t.Errorf("archived web = %+v, want no patch or bundle", web)
+	}
+
+	// Restore must recreate a deleted branch
+	runGit(t, repos[0].Path, "branch", "-D", "feat")
+
+	if err := RestoreWorkspace(cfg, "proj", "feat", false); err != nil {
+		t.Fatalf("RestoreWorkspace() unexpected error: %v", err)
+	}
+
+	if branch, _ := CurrentBranch(apiDir); branch != "feat" {
+		t.Errorf("restored branch = %q, want feat", branch)
+	}
+	if got, _ := RevParse(apiDir, "HEAD"); got != head {
+		t.Errorf("restored HEAD = %s, want %s", got, head)
+	}
+	if data, err := os.ReadFile(filepath.Join(apiDir, "feature.txt")); err != nil || string(data) != "v2\n" {
+		t.Errorf("feature.txt = %q, %v; want uncommitted v2", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(apiDir, "scratch.txt")); err != nil {
+		t.Errorf("scratch.txt missing: %v", err)
+	}
+	if count, _ := StatusChangedCount(filepath.Join(wsPath, "web")); count != 0 {
+		t.Errorf("web has %d changed files, want a clean worktree", count)
+	}
+
+	meta, err := LoadWorkspaceMetadata(wsPath)
+	if err != nil || meta == nil || meta.Name != "feat" {
+		t.Errorf("LoadWorkspaceMetadata() = %+v, %v; want restored metadata", meta, err)
+	}
+	if records, _ := ListArchives("proj"); len(records) != 0 {
+		t.Errorf("archive should be deleted after restore, got %+v", records)
+	}
+}
+
+func TestRestoreWorkspaceFromBundle(t *testing.T) {
+	origin := newTestRepo(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: []Repo{{Name: "api", Path: origin}}}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "feat", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	apiDir := filepath.Join(GetWorkspacePath(cfg, "proj", "feat"), "api")
+	writeAndCommit(t, apiDir, "feature.txt", "v1\n", "add feature")
+	head, _ := RevParse(apiDir, "HEAD")
+
+	if err := ArchiveWorkspace(cfg, profile, "proj", "feat"); err != nil {
+		t.Fatalf("ArchiveWorkspace() unexpected error: %v", err)
+	}
+
+	// Drop the branch and every unreachable commit so only the bundle has it
+	runGit(t, origin, "branch", "-D", "feat")
+	runGit(t, origin, "reflog", "expire", "--expire=now", "--all")
+	runGit(t, origin, "gc", "-q", "--prune=now")
+	if _, err := RevParse(origin, head); err == nil {
+		t.Fatal("commit should be gone before restore")
+	}
+
+	if err := RestoreWorkspace(cfg, "proj", "feat", false); err != nil {
+		t.Fatalf("RestoreWorkspace() unexpected error: %v", err)
+	}
+	if got, _ := RevParse(apiDir, "HEAD"); got != head {
+		t.Errorf("restored HEAD = %s, want %s", got, head)
+	}
+}
+
+func TestRestoreWorkspaceRollsBack(t *testing.T) {
+	repos := []Repo{
+		{Name: "api", Path: newTestRepo(t)},
+		{Name: "web", Path: newTestRepo(t)},
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "feat", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	wsPath := GetWorkspacePath(cfg, "proj", "feat")
+	if err := os.WriteFile(filepath.Join(wsPath, "web", "wip.txt"), []byte("wip\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ArchiveWorkspace(cfg, profile, "proj", "feat"); err != nil {
+		t.Fatalf("ArchiveWorkspace() unexpected error: %v", err)
+	}
+
+	// api's branch has to be recreated; web's patch no longer applies
+	runGit(t, repos[0].Path, "branch", "-D", "feat")
+	dir, _ := ArchiveDir("proj", "feat")
+	if err := os.WriteFile(filepath.Join(dir, "web.patch"), []byte("not a patch\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RestoreWorkspace(cfg, "proj", "feat", false); err == nil {
+		t.Fatal("RestoreWorkspace() expected error for a broken patch, got nil")
+	}
+	if _, err := os.Stat(wsPath); !os.IsNotExist(err) {
+		t.Errorf("workspace directory should be removed, stat err = %v", err)
+	}
+	if RefExists(repos[0].Path, "refs/heads/feat") {
+		t.Error("recreated branch feat should be deleted again")
+	}
+	for _, repo := range repos {
+		entries, err := WorktreeList(repo.Path)
+		if err != nil {
+			t.Fatalf("WorktreeList() unexpected error: %v", err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("%s has %d worktrees, want only the main one", repo.Name, len(entries))
+		}
+	}
+	if _, err := LoadArchive("proj", "feat"); err != nil {
+		t.Errorf("archive should be kept: %v", err)
+	}
+}
+
+func TestRestoreWorkspaceMovedBranch(t *testing.T) {
+	origin := newTestRepo(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: []Repo{{Name: "api", Path: origin}}}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "feat", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	wsPath := GetWorkspacePath(cfg, "proj", "feat")
+	if err := ArchiveWorkspace(cfg, profile, "proj", "feat"); err != nil {
+		t.Fatalf("ArchiveWorkspace() unexpected error: %v", err)
+	}
+
+	// Move the branch behind the archive's back
+	runGit(t, origin, "checkout", "-q", "feat")
+	writeAndCommit(t, origin, "later.txt", "later\n", "later commit")
+	runGit(t, origin, "checkout", "-q", "-")
+	tip, _ := RevParse(origin, "feat")
+
+	if err := RestoreWorkspace(cfg, "proj", "feat", false); err == nil {
+		t.Fatal("RestoreWorkspace() expected error for a moved branch, got nil")
+	}
+	if _, err := os.Stat(wsPath); !os.IsNotExist(err) {
+		t.Errorf("workspace directory should not be created, stat err = %v", err)
+	}
+
+	if err := RestoreWorkspace(cfg, "proj", "feat", true); err != nil {
+		t.Fatalf("RestoreWorkspace(force) unexpected error: %v", err)
+	}
+	if got, _ := RevParse(filepath.Join(wsPath, "api"), "HEAD"); got != tip {
+		t.Errorf("restored HEAD = %s, want the moved tip %s", got, tip)
+	}
+}
+
+func TestArchiveWorkspaceKeepsWorkspaceOnRemovalFailure(t *testing.T) {
+	repos := []Repo{
+		{Name: "api", Path: newTestRepo(t)},
+		{Name: "web", Path: newTestRepo(t)},
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "feat", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	wsPath := GetWorkspacePath(cfg, "proj", "feat")
+	apiDir := filepath.Join(wsPath, "api")
+	if err := os.WriteFile(filepath.Join(apiDir, "wip.txt"), []byte("wip\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A locked worktree cannot be removed even with --force
+	runGit(t, repos[1].Path, "worktree", "lock", filepath.Join(wsPath, "web"))
+
+	if err := ArchiveWorkspace(cfg, profile, "proj", "feat"); err == nil {
+		t.Fatal("ArchiveWorkspace() expected error for a locked worktree, got nil")
+	}
+	if branch, _ := CurrentBranch(apiDir); branch != "feat" {
+		t.Errorf("api branch = %q, want the worktree put back on feat", branch)
+	}
+	if data, err := os.ReadFile(filepath.Join(apiDir, "wip.txt")); err != nil || string(data) != "wip\n" {
+		t.Errorf("wip.txt = %q, %v; want the uncommitted change put back", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(wsPath, "web")); err != nil {
+		t.Errorf("web worktree should be kept: %v", err)
+	}
+	if meta, err := LoadWorkspaceMetadata(wsPath); err != nil || meta == nil {
+		t.Errorf("LoadWorkspaceMetadata() = %+v, %v; want metadata kept", meta, err)
+	}
+	if records, _ := ListArchives("proj"); len(records) != 0 {
+		t.Errorf("archive should be dropped once the workspace is back, got %+v", records)
+	}
+}
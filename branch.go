@@ -0,0 +1,76 @@
+package mangrove
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"text/template"
+)
+
+// DefaultBranchTemplate names each branch after the workspace.
+const DefaultBranchTemplate = "{{.Workspace}}"
+
+// BranchTemplateData is the data available to branch_template.
+type BranchTemplateData struct {
+	Workspace string
+	Profile   string
+	Repo      string
+	User      string
+}
+
+// BranchTemplateFor returns the branch template for a repo:
+// the repo's own template, then the profile's, then DefaultBranchTemplate.
+func (p *Profile) BranchTemplateFor(repo *Repo) string {
+	if repo.BranchTemplate != "" {
+		return repo.BranchTemplate
+	}
+	if p.BranchTemplate != "" {
+		return p.BranchTemplate
+	}
+	return DefaultBranchTemplate
+}
+
+// BranchName resolves the branch name a repo uses in the given workspace.
+func (p *Profile) BranchName(repo *Repo, profileName, workspace string) (string, error) {
+	tmplText := p.BranchTemplateFor(repo)
+	tmpl, err := template.New("branch_template").Option("missingkey=error").Parse(tmplText)
+	if err != nil {
+		return "", fmt.Errorf("invalid branch_template %q for %s: %w", tmplText, repo.Name, err)
+	}
+
+	data := BranchTemplateData{
+		Workspace: workspace,
+		Profile:   profileName,
+		Repo:      repo.Name,
+		User:      currentUserName(),
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render branch_template %q for %s: %w", tmplText, repo.Name, err)
+	}
+
+	branch := strings.TrimSpace(sb.String())
+	if branch == "" {
+		return "", fmt.Errorf("branch_template %q for %s rendered an empty branch name", tmplText, repo.Name)
+	}
+	return branch, nil
+}
+
+// WorkspaceBranch returns the branch a repo uses in a workspace: the one recorded in
+// the workspace metadata if present, otherwise the one resolved from branch_template.
+func WorkspaceBranch(meta *WorkspaceMetadata, profile *Profile, repo *Repo, profileName, workspace string) (string, error) {
+	if rm := meta.FindRepo(repo.Name); rm != nil && rm.Branch != "" {
+		return rm.Branch, nil
+	}
+	return profile.BranchName(repo, profileName, workspace)
+}
+
+// currentUserName returns the login name of the current user, used as {{.User}}.
+func currentUserName() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
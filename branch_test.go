@@ -0,0 +1,129 @@
+package mangrove
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBranchName(t *testing.T) {
+	user := currentUserName()
+
+	tests := []struct {
+		name            string
+		profileTemplate string
+		repoTemplate    string
+		want            string
+		wantErr         bool
+	}{
+		{
+			name: "default uses workspace name",
+			want: "login",
+		},
+		{
+			name:            "profile template",
+			profileTemplate: "feature/{{.Workspace}}",
+			want:            "feature/login",
+		},
+		{
+			name:            "repo template overrides profile",
+			profileTemplate: "feature/{{.Workspace}}",
+			repoTemplate:    "{{.User}}/{{.Workspace}}",
+			want:            user + "/login",
+		},
+		{
+			name:         "profile and repo fields",
+			repoTemplate: "{{.Profile}}/{{.Repo}}/{{.Workspace}}",
+			want:         "proj/api/login",
+		},
+		{
+			name:         "invalid template",
+			repoTemplate: "{{.Workspace",
+			wantErr:      true,
+		},
+		{
+			name:         "unknown field",
+			repoTemplate: "{{.Ticket}}",
+			wantErr:      true,
+		},
+		{
+			name:         "empty result",
+			repoTemplate: "{{if false}}x{{end}}",
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := Repo{Name: "api", BranchTemplate: tt.repoTemplate}
+			profile := &Profile{Repos: []Repo{repo}, BranchTemplate: tt.profileTemplate}
+
+			got, err := profile.BranchName(&repo, "proj", "login")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("BranchName() expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BranchName() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("BranchName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkspaceBranch(t *testing.T) {
+	repo := Repo{Name: "api", BranchTemplate: "feature/{{.Workspace}}"}
+	profile := &Profile{Repos: []Repo{repo}}
+
+	if got, err := WorkspaceBranch(nil, profile, &repo, "proj", "login"); err != nil || got != "feature/login" {
+		t.Errorf("WorkspaceBranch() without metadata = %q, %v; want %q", got, err, "feature/login")
+	}
+
+	meta := &WorkspaceMetadata{Repos: []RepoMetadata{{Name: "api", Branch: "renamed"}}}
+	if got, err := WorkspaceBranch(meta, profile, &repo, "proj", "login"); err != nil || got != "renamed" {
+		t.Errorf("WorkspaceBranch() with metadata = %q, %v; want %q", got, err, "renamed")
+	}
+
+	// A broken template is an error, not a guess
+	broken := Repo{Name: "api", BranchTemplate: "{{.Missing}}"}
+	if got, err := WorkspaceBranch(nil, profile, &broken, "proj", "login"); err == nil {
+		t.Errorf("WorkspaceBranch() with a broken template = %q, want an error", got)
+	}
+}
+
+func TestCreateAndRemoveWorkspaceWithBranchTemplate(t *testing.T) {
+	repos := []Repo{
+		{Name: "backend", Path: newTestRepo(t), BranchTemplate: "feature/{{.Workspace}}"},
+		{Name: "frontend", Path: newTestRepo(t)},
+	}
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos, BranchTemplate: "team/{{.Workspace}}"}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "x", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+
+	wsPath := GetWorkspacePath(cfg, "proj", "x")
+	want := map[string]string{"backend": "feature/x", "frontend": "team/x"}
+	for _, repo := range repos {
+		branch, err := CurrentBranch(filepath.Join(wsPath, repo.Name))
+		if err != nil {
+			t.Fatalf("CurrentBranch(%s) unexpected error: %v", repo.Name, err)
+		}
+		if branch != want[repo.Name] {
+			t.Errorf("%s branch = %q, want %q", repo.Name, branch, want[repo.Name])
+		}
+	}
+
+	if err := RemoveWorkspace(cfg, profile, "proj", "x", true, false); err != nil {
+		t.Fatalf("RemoveWorkspace() unexpected error: %v", err)
+	}
+	for _, repo := range repos {
+		if RefExists(repo.Path, "refs/heads/"+want[repo.Name]) {
+			t.Errorf("%s branch %q should be deleted by --with-branch", repo.Name, want[repo.Name])
+		}
+	}
+}
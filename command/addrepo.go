@@ -0,0 +1,131 @@
+package command
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var (
+	addRepoYes      bool
+	addRepoBase     string
+	addRepoCheckout string
+	addRepoPath     string
+	addRepoName     string
+)
+
+var addRepoCmd = &cobra.Command{
+	Use:   "add-repo [workspace-name] [repo...]",
+	Short: "Add repos to an existing workspace",
+	Long: `Add worktrees for repos to an existing workspace.
+
+Repos are taken from the workspace's profile; by default the profile repos that
+are not yet part of the workspace are offered. Use --path to add a repo that is
+not in the profile; if its name clashes with a profile repo at another path, pick
+another name with --name. Branches and bases are chosen the same way as in mgv new.
+
+Examples:
+  mgv add-repo
+  mgv add-repo feature-login infra
+  mgv add-repo feature-login -y
+  mgv add-repo feature-login --checkout feature/login
+  mgv add-repo feature-login --path ~/repos/docs`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interactive := !addRepoYes
+
+		profileName, wsName, err := resolveWorkspace(args[:min(len(args), 1)])
+		if err != nil {
+			return err
+		}
+		repoArgs := args[min(len(args), 1):]
+
+		profile, _, err := cfg.GetProfile(profileName)
+		if err != nil {
+			return err
+		}
+
+		wsPath := mangrove.GetWorkspacePath(cfg, profileName, wsName)
+		meta, err := mangrove.LoadWorkspaceMetadata(wsPath)
+		if err != nil {
+			return err
+		}
+
+		// Profile repos that are not part of the workspace yet
+		current := mangrove.RepoNames(mangrove.WorkspaceRepos(meta, profile))
+		var candidates []mangrove.Repo
+		for _, repo := range profile.Repos {
+			if !slices.Contains(current, repo.Name) {
+				candidates = append(candidates, repo)
+			}
+		}
+
+		var repos []mangrove.Repo
+		switch {
+		case addRepoPath != "":
+			if len(repoArgs) > 0 {
+				return fmt.Errorf("--path cannot be combined with repo names")
+			}
+			repoPath, err := filepath.Abs(mangrove.ExpandPath(addRepoPath))
+			if err != nil {
+				return err
+			}
+			if !isGitRepoRoot(repoPath) {
+				return fmt.Errorf("%s is not a git repository root", repoPath)
+			}
+			name := addRepoName
+			if name == "" {
+				name = filepath.Base(repoPath)
+			}
+			repos = []mangrove.Repo{{
+				Name:        name,
+				Path:        repoPath,
+				DefaultBase: mangrove.DetectDefaultBranch(repoPath),
+			}}
+		case len(repoArgs) > 0:
+			for _, name := range repoArgs {
+				repo := mangrove.FindRepo(profile.Repos, name)
+				if repo == nil {
+					return fmt.Errorf("repo %q not found in profile %q", name, profileName)
+				}
+				repos = append(repos, *repo)
+			}
+		case len(candidates) == 0:
+			return fmt.Errorf("every repo in profile %q is already part of workspace %q", profileName, wsName)
+		case interactive:
+			names, err := mangrove.SelectRepos(mangrove.RepoNames(candidates))
+			if err != nil {
+				return err
+			}
+			repos = mangrove.FilterReposByName(candidates, names)
+		default:
+			repos = candidates
+		}
+
+		checkout := addRepoCheckout
+		if checkout == "" && interactive {
+			checkout, err = selectCheckout(repos)
+			if err != nil {
+				return err
+			}
+		}
+
+		sources, err := worktreeSources(repos, interactive, addRepoBase, checkout)
+		if err != nil {
+			return err
+		}
+
+		return mangrove.AddRepos(cfg, profile, profileName, wsName, repos, sources)
+	},
+}
+
+func init() {
+	addRepoCmd.Flags().BoolVarP(&addRepoYes, "yes", "y", false, "non-interactive mode (add every missing profile repo with defaults)")
+	addRepoCmd.Flags().StringVarP(&addRepoBase, "base", "b", "", "common base branch for all added repos")
+	addRepoCmd.Flags().StringVarP(&addRepoCheckout, "checkout", "c", "", "check out an existing local or remote branch instead of creating one")
+	addRepoCmd.Flags().StringVar(&addRepoPath, "path", "", "add a repo that is not in the profile")
+	addRepoCmd.Flags().StringVar(&addRepoName, "name", "", "name for the --path repo (default: directory name)")
+	rootCmd.AddCommand(addRepoCmd)
+}
@@ -0,0 +1,128 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var (
+	adoptYes  bool
+	adoptName string
+	adoptAll  bool
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [branch]",
+	Short: "Adopt existing worktrees as a workspace",
+	Long: `Adopt worktrees that were created by hand into mgv workspaces.
+
+Scans the worktrees of every repo in the profile, groups the ones outside base_dir
+by branch, and moves each group into base_dir/<profile>/<name> with git worktree move.
+Adopted workspaces show up in list, status and rm like any other.
+
+The workspace name defaults to the branch name with "/" replaced by "-".
+
+Examples:
+  mgv adopt
+  mgv adopt feature/login
+  mgv adopt feature/login --name login -y
+  mgv adopt --all -y`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interactive := !adoptYes
+
+		profile, profileName, err := resolveProfile(interactive)
+		if err != nil {
+			return err
+		}
+
+		candidates, err := mangrove.FindAdoptCandidates(cfg, profile)
+		if err != nil {
+			return err
+		}
+		if len(candidates) == 0 {
+			return fmt.Errorf("no worktrees to adopt in profile %q", profileName)
+		}
+
+		var selected []mangrove.AdoptCandidate
+		switch {
+		case adoptAll:
+			if len(args) > 0 || adoptName != "" {
+				return fmt.Errorf("--all cannot be combined with a branch or --name")
+			}
+			selected = candidates
+		case len(args) > 0:
+			for _, c := range candidates {
+				if c.Branch == args[0] {
+					selected = append(selected, c)
+				}
+			}
+			if len(selected) == 0 {
+				return fmt.Errorf("no worktrees to adopt on branch %q", args[0])
+			}
+		case interactive:
+			labels := make([]string, len(candidates))
+			for i, c := range candidates {
+				labels[i] = c.Label()
+			}
+			label, err := mangrove.SelectWithFzf(labels, "Adopt:", "Select worktrees to adopt (grouped by branch)")
+			if err != nil {
+				return err
+			}
+			for i := range labels {
+				if labels[i] == label {
+					selected = append(selected, candidates[i])
+				}
+			}
+		default:
+			return fmt.Errorf("branch name or --all is required in non-interactive mode")
+		}
+
+		reader := bufio.NewReader(os.Stdin)
+		for _, c := range selected {
+			name := adoptName
+			if name == "" {
+				name = strings.ReplaceAll(c.Branch, "/", "-")
+				if interactive {
+					fmt.Fprintf(os.Stderr, "? Workspace name for %s (%s): ", c.Branch, name)
+					input, err := reader.ReadString('\n')
+					if err != nil {
+						return fmt.Errorf("failed to read workspace name: %w", err)
+					}
+					if input = strings.TrimSpace(input); input != "" {
+						name = input
+					}
+				}
+			}
+
+			if interactive {
+				for _, wt := range c.Worktrees {
+					fmt.Fprintf(os.Stderr, "  %s  %s\n", mangrove.RepoNameStyle.Render(wt.Repo.Name), mangrove.CollapsePath(wt.Path))
+				}
+				fmt.Fprintf(os.Stderr, "? Move these worktrees into %s? (Y/n): ",
+					mangrove.CollapsePath(mangrove.GetWorkspacePath(cfg, profileName, name)))
+				input, _ := reader.ReadString('\n')
+				if strings.HasPrefix(strings.ToLower(strings.TrimSpace(input)), "n") {
+					return mangrove.ErrCancelled
+				}
+			}
+
+			if err := mangrove.AdoptWorkspace(cfg, profileName, name, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	adoptCmd.Flags().BoolVarP(&adoptYes, "yes", "y", false, "non-interactive mode (skip confirmations)")
+	adoptCmd.Flags().StringVarP(&adoptName, "name", "n", "", "workspace name (default: branch name with / replaced by -)")
+	adoptCmd.Flags().BoolVar(&adoptAll, "all", false, "adopt every group of worktrees")
+	rootCmd.AddCommand(adoptCmd)
+}
@@ -2,9 +2,11 @@ package command
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/Koutaro-Hanabusa/mangrove"
@@ -12,11 +14,16 @@ import (
 )
 
 var (
-	applyYes    bool
-	applyRepos  []string
-	applyMethod string
-	applyBase   string
-	applyBranch string
+	applyYes      bool
+	applyFilter   repoFilter
+	applyMethod   string
+	applyBase     string
+	applyBranch   string
+	applyMessage  string
+	applyIsolated bool
+	applyDryRun   bool
+	applyContinue bool
+	applyAbort    bool
 )
 
 var applyCmd = &cobra.Command{
@@ -24,54 +31,50 @@ var applyCmd = &cobra.Command{
 	Short: "Apply worktree changes to the original repo",
 	Long: `Apply changes from a workspace worktree back to the original repository.
 
-Supports two methods:
-  stash  - Stash uncommitted changes in worktree, then pop them on a new branch in the original repo.
-  merge  - Merge the worktree branch into a new branch in the original repo.
+Supports five methods:
+  stash        - Stash uncommitted changes (including untracked files) in worktree, then apply them on a new branch in the original repo.
+  merge        - Merge the worktree branch into a new branch in the original repo.
+  rebase       - Replay the worktree branch's commits onto the base on a new branch in the original repo.
+  squash       - Commit the worktree branch's changes as a single commit on a new branch (--message or prompted).
+  cherry-pick  - Cherry-pick commits of the worktree branch, picked with fzf (all of them with -y), onto a new branch.
+
+With --isolated the new branch is built in a temporary worktree instead, so the
+original repo's checked-out branch and working tree are never touched and may
+have uncommitted changes. Stashed changes are then committed on the new branch.
+
+--dry-run shows, for each repo, the method, base and new branch, the commits and
+files that would move, whether the original repo is dirty and the files expected
+to conflict (predicted with git merge-tree), without changing anything.
+
+Every step is journaled in the workspace. Apply stops on the first conflict and
+leaves it in the original repo. Resolve it there (stage the files for merge),
+then run --continue to resume with the remaining repos, or --abort to restore
+every repo: original branches, new branches and the worktree changes.
 
 Examples:
   mgv apply
   mgv apply feature-login
   mgv apply feature-login --method stash --base main --branch apply/feature-login
   mgv apply feature-login --repo api --repo web
-  mgv apply feature-login -y -m merge -b main`,
+  mgv apply feature-login --skip-repo tag:docs
+  mgv apply feature-login -y -m merge -b main
+  mgv apply feature-login -y -m squash --message "Add login"
+  mgv apply feature-login -y -m merge --isolated
+  mgv apply feature-login -y -m merge --dry-run
+  mgv apply feature-login --continue
+  mgv apply feature-login --abort`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		interactive := !applyYes
-
-		var profileName, wsName string
-
-		if len(args) > 0 {
-			wsName = args[0]
-			_, pName, err := resolveProfile(profileFlag == "")
-			if err != nil {
-				return err
-			}
-			profileName = pName
-		} else {
-			if !mangrove.IsFzfAvailable() {
-				return fmt.Errorf("fzf is required for interactive mode. Install with: brew install fzf")
-			}
-
-			workspaces, err := mangrove.ListWorkspaces(cfg, profileFlag)
-			if err != nil {
-				return err
-			}
-			if len(workspaces) == 0 {
-				return fmt.Errorf("no workspaces found")
-			}
-
-			labels := mangrove.WorkspaceLabels(workspaces)
-			selected, err := mangrove.SelectWorkspace(labels)
-			if err != nil {
-				return err
-			}
+		if applyContinue && applyAbort {
+			return fmt.Errorf("--continue and --abort cannot be used together")
+		}
+		if applyDryRun && (applyContinue || applyAbort) {
+			return fmt.Errorf("--dry-run cannot be used with --continue or --abort")
+		}
 
-			pName, wName, err := mangrove.ParseWorkspaceLabel(selected)
-			if err != nil {
-				return err
-			}
-			profileName = pName
-			wsName = wName
+		profileName, wsName, err := resolveWorkspace(args)
+		if err != nil {
+			return err
 		}
 
 		profile, _, err := cfg.GetProfile(profileName)
@@ -80,11 +83,8 @@ Examples:
 		}
 
 		wsPath := mangrove.GetWorkspacePath(cfg, profileName, wsName)
-
-		// Build set of target repos if --repo is specified
-		repoFilter := make(map[string]bool)
-		for _, r := range applyRepos {
-			repoFilter[r] = true
+		if _, err := os.Stat(wsPath); os.IsNotExist(err) {
+			return fmt.Errorf("workspace %q not found at %s", wsName, wsPath)
 		}
 
 		fmt.Fprintf(os.Stderr, "\nApplying workspace: %s/%s\n",
@@ -92,206 +92,327 @@ Examples:
 			mangrove.RepoNameStyle.Render(wsName),
 		)
 
-		for _, repo := range profile.Repos {
-			if len(repoFilter) > 0 && !repoFilter[repo.Name] {
-				continue
+		var state *mangrove.ApplyState
+		switch {
+		case applyAbort:
+			err = mangrove.AbortApply(wsPath)
+			if err == nil {
+				mangrove.PrintSuccess("Apply aborted")
+				fmt.Fprintln(os.Stderr)
+				return nil
 			}
-
-			wtDir := filepath.Join(wsPath, repo.Name)
-			if _, err := os.Stat(wtDir); os.IsNotExist(err) {
-				mangrove.PrintWarning("%s: worktree not found, skipping", repo.Name)
-				continue
+		case applyContinue:
+			state, err = mangrove.ContinueApply(wsPath)
+		default:
+			targets, perr := planApply(profile, wsPath, wsName)
+			if perr != nil {
+				return perr
 			}
+			if applyDryRun {
+				plans := mangrove.PlanApply(targets)
+				if machineOutput() {
+					return writeOutput(plans)
+				}
+				mangrove.PrintApplyPlan(plans)
+				fmt.Fprintln(os.Stderr)
+				return nil
+			}
+			if len(targets) == 0 {
+				fmt.Fprintln(os.Stderr)
+				return nil
+			}
+			fmt.Fprintln(os.Stderr)
+			state, err = mangrove.StartApply(wsPath, targets)
+		}
 
-			fmt.Fprintf(os.Stderr, "\n[%s]\n", mangrove.RepoNameStyle.Render(repo.Name))
-
-			// Show status
-			branch, err := mangrove.CurrentBranch(wtDir)
-			if err != nil {
-				mangrove.PrintError("%s: failed to get branch: %v", repo.Name, err)
-				continue
+		var conflict *mangrove.ApplyConflictError
+		if errors.As(err, &conflict) {
+			mangrove.PrintError("%v", conflict)
+			mangrove.PrintInfo("Resolve the conflicts in %s, then run:", conflict.RepoPath)
+			mangrove.PrintInfo("  mgv apply %s --continue   (or --abort to restore every repo)", wsName)
+			fmt.Fprintln(os.Stderr)
+			return fmt.Errorf("apply stopped on conflict in %s", conflict.RepoName)
+		}
+		if err != nil {
+			if !errors.Is(err, mangrove.ErrNoApplyInProgress) {
+				mangrove.PrintInfo("Fix the problem and run mgv apply %s --continue, or --abort to restore every repo", wsName)
 			}
+			return err
+		}
 
-			changedCount, err := mangrove.StatusChangedCount(wtDir)
+		// Run post_apply hooks in the original repos that received changes
+		var applied []mangrove.HookRepo
+		for _, rs := range state.Applied() {
+			applied = append(applied, mangrove.HookRepo{
+				Name:   rs.Name,
+				Branch: rs.NewBranch,
+				Base:   rs.Base,
+				Dir:    rs.RepoPath,
+			})
+		}
+		if len(applied) > 0 {
+			_, err := mangrove.RunHooks(profile.Hooks.PostApply, mangrove.HookContext{
+				Stage:         mangrove.HookPostApply,
+				Profile:       profileName,
+				Workspace:     wsName,
+				WorkspacePath: wsPath,
+				Dir:           wsPath,
+				Repos:         applied,
+			})
 			if err != nil {
-				mangrove.PrintError("%s: failed to get status: %v", repo.Name, err)
-				continue
+				return err
 			}
+		}
 
-			ahead, behind, _ := mangrove.AheadBehind(repo.Path, repo.GetDefaultBase(), branch)
-			mangrove.PrintRepoStatus(repo.Name, branch, changedCount, ahead, behind, repo.GetDefaultBase())
+		fmt.Fprintln(os.Stderr)
+		return nil
+	},
+}
+
+// planApply shows the status of each selected repo and asks (or uses the flags for)
+// the method, base branch and new branch name. Repos that are skipped or cannot be
+// applied are left out.
+func planApply(profile *mangrove.Profile, wsPath, wsName string) ([]mangrove.ApplyTarget, error) {
+	interactive := !applyYes
+
+	repos, meta, err := workspaceRepos(profile, wsPath, &applyFilter)
+	if err != nil {
+		return nil, err
+	}
 
-			// Guard: check original repo for uncommitted changes
+	var targets []mangrove.ApplyTarget
+	for _, repo := range repos {
+		wtDir := filepath.Join(wsPath, repo.Name)
+		if _, err := os.Stat(wtDir); os.IsNotExist(err) {
+			mangrove.PrintWarning("%s: worktree not found, skipping", repo.Name)
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "\n[%s]\n", mangrove.RepoNameStyle.Render(repo.Name))
+
+		// Show status
+		branch, err := mangrove.CurrentBranch(wtDir)
+		if err != nil {
+			mangrove.PrintError("%s: failed to get branch: %v", repo.Name, err)
+			continue
+		}
+
+		changedCount, err := mangrove.StatusChangedCount(wtDir)
+		if err != nil {
+			mangrove.PrintError("%s: failed to get status: %v", repo.Name, err)
+			continue
+		}
+
+		wsBase := meta.RepoBase(&repo)
+		ahead, behind, _ := mangrove.AheadBehind(repo.Path, wsBase, branch)
+		mangrove.PrintRepoStatus(repo.Name, branch, changedCount, ahead, behind, wsBase)
+
+		// Guard: check original repo for uncommitted changes, unless it is left untouched.
+		// A dry run reports it in the plan instead.
+		if !applyIsolated && !applyDryRun {
 			origStatus, err := mangrove.StatusPorcelain(repo.Path)
 			if err != nil {
 				mangrove.PrintError("%s: failed to check original repo status: %v", repo.Name, err)
 				continue
 			}
 			if origStatus != "" {
-				mangrove.PrintError("%s: original repo has uncommitted changes. Please commit or stash first.", repo.Name)
+				mangrove.PrintError("%s: original repo has uncommitted changes. Please commit or stash first, or use --isolated.", repo.Name)
 				continue
 			}
+		}
 
-			// Select method
-			method := applyMethod
-			if method == "" {
-				if interactive {
-					if !mangrove.IsFzfAvailable() {
-						return fmt.Errorf("fzf is required for interactive mode")
-					}
-					selected, err := mangrove.SelectMethod(repo.Name)
-					if err != nil {
-						return err
-					}
-					method = selected
-				} else {
-					return fmt.Errorf("--method is required in non-interactive mode")
+		// Select method
+		method := applyMethod
+		if method == "" {
+			if interactive {
+				if !mangrove.IsFzfAvailable() {
+					return nil, fmt.Errorf("fzf is required for interactive mode")
+				}
+				selected, err := mangrove.SelectMethod(repo.Name)
+				if err != nil {
+					return nil, err
 				}
+				method = selected
+			} else {
+				return nil, fmt.Errorf("--method is required in non-interactive mode")
 			}
+		}
 
-			if method == "skip" {
-				mangrove.PrintInfo("Skipped %s", repo.Name)
-				continue
-			}
+		if method == "skip" {
+			mangrove.PrintInfo("Skipped %s", repo.Name)
+			continue
+		}
+		if !slices.Contains(mangrove.ApplyMethods, method) {
+			mangrove.PrintError("%s: unknown method %q", repo.Name, method)
+			continue
+		}
 
-			// Guard: stash requires uncommitted changes
-			if method == "stash" && changedCount == 0 {
-				mangrove.PrintWarning("%s: no uncommitted changes to stash, skipping", repo.Name)
-				continue
-			}
+		// Guard: stash requires uncommitted changes
+		if method == mangrove.ApplyStash && changedCount == 0 {
+			mangrove.PrintWarning("%s: no uncommitted changes to stash, skipping", repo.Name)
+			continue
+		}
 
-			// Guard: merge requires commits ahead
-			if method == "merge" && ahead == 0 {
-				mangrove.PrintWarning("%s: no commits ahead to merge, skipping", repo.Name)
-				continue
-			}
+		// Guard: every other method requires commits ahead
+		if method != mangrove.ApplyStash && ahead == 0 {
+			mangrove.PrintWarning("%s: no commits ahead to %s, skipping", repo.Name, method)
+			continue
+		}
 
-			// Select base branch
-			baseBranch := applyBase
-			if baseBranch == "" {
-				if interactive {
-					prompt := fmt.Sprintf("[%s] Base branch:", repo.Name)
-					selected, err := mangrove.SelectBranch(repo.Path, prompt, repo.GetDefaultBase())
-					if err != nil {
-						return err
-					}
-					baseBranch = selected
-				} else {
-					baseBranch = repo.GetDefaultBase()
+		// Select base branch
+		baseBranch := applyBase
+		if baseBranch == "" {
+			if interactive {
+				prompt := fmt.Sprintf("[%s] Base branch:", repo.Name)
+				selected, err := mangrove.SelectBranch(repo.Path, prompt, wsBase)
+				if err != nil {
+					return nil, err
 				}
+				baseBranch = selected
+			} else {
+				baseBranch = wsBase
 			}
+		}
 
-			// Determine new branch name
-			newBranch := applyBranch
-			if newBranch == "" {
-				defaultName := fmt.Sprintf("apply/%s", wsName)
-				if interactive {
-					fmt.Fprintf(os.Stderr, "  ? New branch name [%s]: ", defaultName)
-					reader := bufio.NewReader(os.Stdin)
-					input, err := reader.ReadString('\n')
-					if err != nil {
-						return fmt.Errorf("failed to read branch name: %w", err)
-					}
-					input = strings.TrimSpace(input)
-					if input != "" {
-						newBranch = input
-					} else {
-						newBranch = defaultName
-					}
+		// Determine new branch name
+		newBranch := applyBranch
+		if newBranch == "" {
+			defaultName := fmt.Sprintf("apply/%s", wsName)
+			if interactive {
+				fmt.Fprintf(os.Stderr, "  ? New branch name [%s]: ", defaultName)
+				reader := bufio.NewReader(os.Stdin)
+				input, err := reader.ReadString('\n')
+				if err != nil {
+					return nil, fmt.Errorf("failed to read branch name: %w", err)
+				}
+				input = strings.TrimSpace(input)
+				if input != "" {
+					newBranch = input
 				} else {
 					newBranch = defaultName
 				}
+			} else {
+				newBranch = defaultName
 			}
+		}
 
-			// Execute
-			switch method {
-			case "stash":
-				if err := applyStash(wtDir, repo.Path, newBranch, baseBranch, repo.Name); err != nil {
-					mangrove.PrintError("%s: stash apply failed: %v", repo.Name, err)
-					continue
-				}
-			case "merge":
-				if err := applyMerge(wtDir, repo.Path, branch, newBranch, baseBranch, repo.Name); err != nil {
-					mangrove.PrintError("%s: merge apply failed: %v", repo.Name, err)
-					continue
+		target := mangrove.ApplyTarget{
+			RepoName:  repo.Name,
+			RepoPath:  repo.Path,
+			Worktree:  wtDir,
+			Method:    method,
+			Branch:    branch,
+			NewBranch: newBranch,
+			Base:      baseBranch,
+			Isolated:  applyIsolated,
+		}
+
+		switch method {
+		case mangrove.ApplyStash:
+			// Stashed changes are committed on the new branch when isolated
+			if applyIsolated {
+				message, err := applyCommitMessage(fmt.Sprintf("Apply uncommitted changes from %s", branch), interactive)
+				if err != nil {
+					return nil, err
 				}
-			default:
-				mangrove.PrintError("%s: unknown method %q", repo.Name, method)
+				target.Message = message
+			}
+		case mangrove.ApplySquash:
+			message, err := mangrove.SquashMessage(repo.Path, baseBranch, branch)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", repo.Name, err)
+			}
+			if target.Message, err = applyCommitMessage(message, interactive); err != nil {
+				return nil, err
+			}
+		case mangrove.ApplyCherryPick:
+			commits, err := pickCommits(repo.Name, repo.Path, baseBranch, branch, interactive)
+			if err != nil {
+				return nil, err
+			}
+			if len(commits) == 0 {
+				mangrove.PrintWarning("%s: no commits to cherry-pick, skipping", repo.Name)
 				continue
 			}
-
-			mangrove.PrintSuccess("%s: applied via %s → %s (base: %s)", repo.Name, method, newBranch, baseBranch)
+			target.Commits = commits
 		}
 
-		fmt.Fprintln(os.Stderr)
-		return nil
-	},
+		targets = append(targets, target)
+	}
+
+	return targets, nil
 }
 
-// applyStash applies worktree changes via stash push/pop.
-func applyStash(wtDir, repoPath, newBranch, baseBranch, repoName string) error {
-	// Step 1: stash push in worktree
-	msg := fmt.Sprintf("mgv-apply: %s", newBranch)
-	if err := mangrove.StashPush(wtDir, msg); err != nil {
-		return fmt.Errorf("stash push failed: %w", err)
+// applyCommitMessage returns the message of the commit made on the new branch: --message,
+// or the given default, which can be replaced in interactive mode.
+func applyCommitMessage(message string, interactive bool) (string, error) {
+	if applyMessage != "" {
+		return applyMessage, nil
 	}
-
-	// Step 2: create new branch in original repo
-	if err := mangrove.CheckoutNewBranch(repoPath, newBranch, baseBranch); err != nil {
-		// Rollback: pop stash back in worktree
-		mangrove.PrintWarning("%s: rolling back stash to worktree...", repoName)
-		_ = mangrove.StashPop(wtDir)
-		return fmt.Errorf("checkout -b failed: %w", err)
+	if !interactive {
+		return message, nil
 	}
 
-	// Step 3: pop stash in original repo (shared .git)
-	if err := mangrove.StashPop(repoPath); err != nil {
-		// Rollback: go back to previous branch, delete new branch, pop stash in worktree
-		mangrove.PrintWarning("%s: rolling back...", repoName)
-		_ = mangrove.CheckoutBranch(repoPath, baseBranch)
-		_ = mangrove.BranchDelete(repoPath, newBranch, true)
-		_ = mangrove.StashPop(wtDir)
-		return fmt.Errorf("stash pop failed: %w", err)
+	subject, _, _ := strings.Cut(message, "\n")
+	fmt.Fprintf(os.Stderr, "  ? Commit message [%s]: ", subject)
+	reader := bufio.NewReader(os.Stdin)
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read commit message: %w", err)
 	}
-
-	return nil
+	if input = strings.TrimSpace(input); input != "" {
+		return input, nil
+	}
+	return message, nil
 }
 
-// applyMerge applies worktree changes via merge.
-func applyMerge(wtDir, repoPath, wtBranch, newBranch, baseBranch, repoName string) error {
-	// Record current branch of original repo
-	origBranch, err := mangrove.CurrentBranch(repoPath)
+// pickCommits returns the commits of branch that are not in base, oldest first.
+// In interactive mode only the commits picked with fzf are returned.
+func pickCommits(repoName, repoPath, base, branch string, interactive bool) ([]string, error) {
+	entries, err := mangrove.CommitLog(repoPath, base+".."+branch)
 	if err != nil {
-		return fmt.Errorf("failed to get current branch: %w", err)
+		return nil, fmt.Errorf("%s: %w", repoName, err)
 	}
 
-	// Step 1: create new branch in original repo
-	if err := mangrove.CheckoutNewBranch(repoPath, newBranch, baseBranch); err != nil {
-		return fmt.Errorf("checkout -b failed: %w", err)
+	var commits []string
+	if !interactive {
+		for _, e := range entries {
+			commits = append(commits, e.Hash)
+		}
+		return commits, nil
 	}
-
-	// Step 2: merge worktree branch
-	if err := mangrove.Merge(repoPath, wtBranch); err != nil {
-		// Rollback: go back to original branch, delete new branch
-		mangrove.PrintWarning("%s: rolling back...", repoName)
-		_ = mangrove.CheckoutBranch(repoPath, origBranch)
-		_ = mangrove.BranchDelete(repoPath, newBranch, true)
-		return fmt.Errorf("merge failed: %w", err)
+	if len(entries) == 0 {
+		return nil, nil
 	}
 
-	// Step 3: return to original branch
-	if err := mangrove.CheckoutBranch(repoPath, origBranch); err != nil {
-		mangrove.PrintWarning("%s: failed to return to %s: %v", repoName, origBranch, err)
+	items := make([]string, len(entries))
+	for i, e := range entries {
+		items[i] = fmt.Sprintf("%s %s", e.Hash[:min(7, len(e.Hash))], e.Subject)
+	}
+	selected, err := mangrove.SelectCommits(repoName, items)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	// Keep the log order so commits are applied oldest first
+	for i, item := range items {
+		if slices.Contains(selected, item) {
+			commits = append(commits, entries[i].Hash)
+		}
+	}
+	return commits, nil
 }
 
 func init() {
 	applyCmd.Flags().BoolVarP(&applyYes, "yes", "y", false, "non-interactive mode")
-	applyCmd.Flags().StringSliceVarP(&applyRepos, "repo", "r", nil, "target specific repos")
-	applyCmd.Flags().StringVarP(&applyMethod, "method", "m", "", "apply method: stash or merge")
+	applyFilter.addFlags(applyCmd)
+	applyCmd.Flags().StringVarP(&applyMethod, "method", "m", "", "apply method: stash, merge, rebase, squash or cherry-pick")
 	applyCmd.Flags().StringVarP(&applyBase, "base", "b", "", "base branch for new branch")
 	applyCmd.Flags().StringVar(&applyBranch, "branch", "", "new branch name")
+	applyCmd.Flags().StringVar(&applyMessage, "message", "", "commit message for the squash method (and stash with --isolated)")
+	applyCmd.Flags().BoolVar(&applyIsolated, "isolated", false, "build the new branch in a temporary worktree, leaving the original repo untouched")
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "show what would be applied to each repo without changing anything")
+	applyCmd.Flags().BoolVar(&applyContinue, "continue", false, "resume after resolving a conflict")
+	applyCmd.Flags().BoolVar(&applyAbort, "abort", false, "abort the apply and restore every repo")
 	rootCmd.AddCommand(applyCmd)
 }
@@ -0,0 +1,40 @@
+package command
+
+import (
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive [workspace-name]",
+	Short: "Archive a workspace and remove its worktrees",
+	Long: `Save the branch, HEAD and uncommitted changes (including untracked files) of
+each repo in a workspace under ~/.config/mgv/archives, then remove the worktrees
+and the workspace directory. Branches are kept, and commits ahead of the base are
+also saved to a bundle so a deleted branch can be recovered.
+
+Hooks do not run. Use mgv restore to bring the workspace back and
+mgv list --archived to see archived workspaces.
+
+Examples:
+  mgv archive
+  mgv archive feature-login`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profileName, wsName, err := resolveWorkspace(args)
+		if err != nil {
+			return err
+		}
+
+		profile, _, err := cfg.GetProfile(profileName)
+		if err != nil {
+			return err
+		}
+
+		return mangrove.ArchiveWorkspace(cfg, profile, profileName, wsName)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+}
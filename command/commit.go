@@ -0,0 +1,171 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var (
+	commitMessage      string
+	commitRepoMessages []string
+	commitPickFiles    bool
+	commitFilter       repoFilter
+)
+
+var commitCmd = &cobra.Command{
+	Use:   "commit [workspace-name] -m <message>",
+	Short: "Commit changes in every repo of a workspace",
+	Long: `Stage and commit all changes (including untracked files) in each repo of a
+workspace with the same message. Repos without changes are skipped, and a
+summary of the created commits is printed at the end.
+
+Use --repo-message to give a repo its own message, and --pick-files to choose
+the files to commit in each repo with fzf (Esc skips the repo).
+Commit hooks run as usual; if a commit fails in one repo the others still go
+ahead and mgv exits non-zero.
+
+Examples:
+  mgv commit -m "Rename user id field"
+  mgv commit feature-login -m "Add login API" --repo-message frontend-A="Call login API"
+  mgv commit feature-login -m "Add login API" --pick-files
+  mgv commit feature-login -m "Update docs" --repo docs`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		overrides, err := parseRepoMessages(commitRepoMessages)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(commitMessage) == "" {
+			return fmt.Errorf("commit message is required: use -m <message>")
+		}
+		if commitPickFiles && !mangrove.IsFzfAvailable() {
+			return fmt.Errorf("fzf is required for --pick-files. Install with: brew install fzf")
+		}
+
+		profileName, wsName, err := resolveWorkspace(args)
+		if err != nil {
+			return err
+		}
+
+		profile, _, err := cfg.GetProfile(profileName)
+		if err != nil {
+			return err
+		}
+
+		wsPath := mangrove.GetWorkspacePath(cfg, profileName, wsName)
+		repos, _, err := workspaceRepos(profile, wsPath, &commitFilter)
+		if err != nil {
+			return err
+		}
+		for name := range overrides {
+			if mangrove.FindRepo(repos, name) == nil {
+				return fmt.Errorf("--repo-message: repo %q is not a target of this commit", name)
+			}
+		}
+
+		var targets []mangrove.CommitTarget
+		for _, repo := range repos {
+			repoDir := filepath.Join(wsPath, repo.Name)
+			if _, err := os.Stat(repoDir); os.IsNotExist(err) {
+				mangrove.PrintWarning("Skipping %s: directory not found", repo.Name)
+				continue
+			}
+
+			target := mangrove.CommitTarget{Name: repo.Name, Dir: repoDir, Message: commitMessage}
+			if msg, ok := overrides[repo.Name]; ok {
+				target.Message = msg
+			}
+
+			if commitPickFiles {
+				paths, err := pickCommitFiles(repo.Name, repoDir)
+				if errors.Is(err, mangrove.ErrCancelled) {
+					mangrove.PrintInfo("Skipping %s", repo.Name)
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				if len(paths) == 0 {
+					continue
+				}
+				target.Paths = paths
+			}
+
+			targets = append(targets, target)
+		}
+
+		results := mangrove.CommitRepos(targets)
+
+		if machineOutput() {
+			if results == nil {
+				results = []mangrove.CommitResult{}
+			}
+			if err := writeOutput(results); err != nil {
+				return err
+			}
+		} else {
+			mangrove.PrintCommitSummary(results)
+			fmt.Fprintln(os.Stderr)
+		}
+
+		if failed := mangrove.CountFailed(results); failed > 0 {
+			return fmt.Errorf("commit failed in %d of %d repos", failed, len(results))
+		}
+		return nil
+	},
+}
+
+// parseRepoMessages parses --repo-message values of the form repo=message.
+func parseRepoMessages(values []string) (map[string]string, error) {
+	overrides := make(map[string]string, len(values))
+	for _, v := range values {
+		name, msg, ok := strings.Cut(v, "=")
+		if !ok || name == "" || strings.TrimSpace(msg) == "" {
+			return nil, fmt.Errorf("invalid --repo-message %q: use repo=message", v)
+		}
+		overrides[name] = msg
+	}
+	return overrides, nil
+}
+
+// pickCommitFiles lets the user choose which changed files of a repo to commit.
+// It returns no paths if the repo has no changes.
+func pickCommitFiles(repoName, repoDir string) ([]string, error) {
+	files, err := mangrove.ChangedFiles(repoDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	items := make([]string, len(files))
+	for i, f := range files {
+		items[i] = f.Status + " " + f.Path
+	}
+	selected, err := mangrove.SelectFiles(repoName, items)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(selected))
+	for i, item := range selected {
+		// Items are "XY path"
+		paths[i] = item[3:]
+	}
+	return paths, nil
+}
+
+func init() {
+	commitCmd.Flags().StringVarP(&commitMessage, "message", "m", "", "commit message used in every repo")
+	commitCmd.Flags().StringArrayVar(&commitRepoMessages, "repo-message", nil, "use a different message for one repo, as repo=message (repeatable)")
+	commitCmd.Flags().BoolVar(&commitPickFiles, "pick-files", false, "choose the files to commit in each repo with fzf")
+	commitFilter.addFlags(commitCmd)
+	rootCmd.AddCommand(commitCmd)
+}
@@ -0,0 +1,120 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffStaged bool
+	diffBase   bool
+	diffPatch  bool
+	diffFilter repoFilter
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [workspace-name]",
+	Short: "Show the changes in every repo of a workspace",
+	Long: `Show the diff of each repo in a workspace, grouped under a header per repo.
+
+By default the working tree is compared against the index, like git diff.
+Use --staged to show staged changes, or --base to show everything done in the
+workspace (commits and working tree) since the commit it was created from.
+
+With --patch the output is a single unified patch whose paths are prefixed with
+the repo name (a/<repo>/<file>), which applies from the workspace root.
+Output goes through $PAGER when stdout is a terminal.
+
+Examples:
+  mgv diff
+  mgv diff feature-login --staged
+  mgv diff feature-login --base
+  mgv diff feature-login --base --patch > feature-login.patch`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if diffStaged && diffBase {
+			return fmt.Errorf("--staged and --base cannot be used together")
+		}
+		mode := mangrove.DiffWorktree
+		switch {
+		case diffStaged:
+			mode = mangrove.DiffStaged
+		case diffBase:
+			mode = mangrove.DiffBase
+		}
+
+		targets, err := diffTargets(args, &diffFilter)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		changed, err := mangrove.WriteDiff(&buf, targets, mode, diffPatch, usePager())
+		if err != nil {
+			return err
+		}
+		if changed == 0 {
+			fmt.Fprintln(os.Stderr, "No changes.")
+			return nil
+		}
+		return page(buf.Bytes())
+	},
+}
+
+// diffTargets resolves the workspace from args and returns its repos as diff targets.
+func diffTargets(args []string, filter *repoFilter) ([]mangrove.DiffTarget, error) {
+	profileName, wsName, err := resolveWorkspace(args)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, _, err := cfg.GetProfile(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	wsPath := mangrove.GetWorkspacePath(cfg, profileName, wsName)
+	repos, meta, err := workspaceRepos(profile, wsPath, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []mangrove.DiffTarget
+	for _, repo := range repos {
+		repoDir := filepath.Join(wsPath, repo.Name)
+		if _, err := os.Stat(repoDir); os.IsNotExist(err) {
+			mangrove.PrintWarning("Skipping %s: directory not found", repo.Name)
+			continue
+		}
+		branch, err := mangrove.CurrentBranch(repoDir)
+		if err != nil {
+			if branch, err = mangrove.WorkspaceBranch(meta, profile, &repo, profileName, wsName); err != nil {
+				return nil, err
+			}
+		}
+		target := mangrove.DiffTarget{
+			Name:   repo.Name,
+			Dir:    repoDir,
+			Branch: branch,
+			Base:   meta.RepoBase(&repo),
+		}
+		if rm := meta.FindRepo(repo.Name); rm != nil {
+			target.BaseCommit = rm.BaseCommit
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffStaged, "staged", false, "show staged changes")
+	diffCmd.Flags().BoolVar(&diffBase, "base", false, "show all changes since the workspace was created")
+	diffCmd.Flags().BoolVar(&diffPatch, "patch", false, "write a single patch with repo-prefixed paths")
+	diffFilter.addFlags(diffCmd)
+	rootCmd.AddCommand(diffCmd)
+}
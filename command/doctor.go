@@ -0,0 +1,117 @@
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorFix            bool
+	doctorDeleteBranches bool
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Detect and repair broken worktrees",
+	Long: `Cross-check the worktrees registered in every profile repo against base_dir.
+
+Reports:
+  orphan    worktree registered under base_dir whose directory is gone
+  missing   workspace repo whose worktree is gone or no longer registered
+  locked    locked worktree (never changed by --fix)
+  prunable  worktree that git reports as prunable
+  branch    branch mgv created for a workspace that is gone (archived
+            workspaces keep theirs)
+
+Use --fix to prune stale registrations and re-link or re-create missing worktrees.
+Add --delete-branches to also delete leftover branches (only if fully merged).
+Exits non-zero if problems remain.
+
+Examples:
+  mgv doctor
+  mgv doctor --profile project-a
+  mgv doctor --fix
+  mgv doctor --fix --delete-branches`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		issues, err := mangrove.Diagnose(cfg, profileFlag)
+		if err != nil {
+			return err
+		}
+
+		var fixErr error
+		if doctorFix {
+			fixErr = mangrove.FixIssues(issues, doctorDeleteBranches)
+		}
+
+		remaining := 0
+		for _, issue := range issues {
+			if !issue.Fixed {
+				remaining++
+			}
+		}
+
+		if machineOutput() {
+			if issues == nil {
+				issues = []mangrove.DoctorIssue{}
+			}
+			if err := writeOutput(issues); err != nil {
+				return err
+			}
+		} else {
+			printDoctorIssues(issues)
+		}
+
+		if fixErr != nil {
+			mangrove.PrintError("%v", fixErr)
+		}
+		if remaining > 0 {
+			return fmt.Errorf("%d problem(s) remaining", remaining)
+		}
+		return nil
+	},
+}
+
+// printDoctorIssues prints each issue and whether it was fixed.
+func printDoctorIssues(issues []mangrove.DoctorIssue) {
+	fmt.Fprintln(os.Stderr)
+	if len(issues) == 0 {
+		mangrove.PrintSuccess("No problems found")
+		fmt.Fprintln(os.Stderr)
+		return
+	}
+
+	for _, issue := range issues {
+		line := fmt.Sprintf("%-8s  %s  %s", issue.Kind, mangrove.RepoNameStyle.Render(issue.Label()), issue.Detail)
+		if issue.Branch != "" {
+			line += " " + mangrove.DimStyle.Render("("+issue.Branch+")")
+		}
+		if issue.Path != "" && issue.Kind != mangrove.IssueBranch {
+			line += "\n      " + mangrove.DimStyle.Render(issue.Path)
+		}
+
+		switch {
+		case issue.Fixed:
+			mangrove.PrintSuccess("fixed: %s", line)
+		case doctorFix || !issue.Fixable(true):
+			mangrove.PrintError("%s", line)
+		default:
+			mangrove.PrintWarning("%s", line)
+		}
+	}
+
+	if !doctorFix {
+		fmt.Fprintln(os.Stderr)
+		mangrove.PrintInfo("Run mgv doctor --fix to repair (add --delete-branches to delete leftover branches)")
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "prune stale worktrees and re-link or re-create missing ones")
+	doctorCmd.Flags().BoolVar(&doctorDeleteBranches, "delete-branches", false, "with --fix, also delete leftover branches that are fully merged")
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,97 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dropRepoYes        bool
+	dropRepoWithBranch bool
+	dropRepoForce      bool
+)
+
+var dropRepoCmd = &cobra.Command{
+	Use:   "drop-repo [workspace-name] [repo...]",
+	Short: "Remove repos from a workspace",
+	Long: `Remove the worktrees of some repos from a workspace, keeping the rest of it.
+
+Interactive mode: presents the workspace's repos to choose from.
+Use --with-branch to also delete the local branches.
+Use --force to remove worktrees with uncommitted changes or while a sync or
+apply is unfinished.
+
+Examples:
+  mgv drop-repo
+  mgv drop-repo feature-login infra
+  mgv drop-repo feature-login infra -y --with-branch`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interactive := !dropRepoYes
+
+		profileName, wsName, err := resolveWorkspace(args[:min(len(args), 1)])
+		if err != nil {
+			return err
+		}
+		repoArgs := args[min(len(args), 1):]
+
+		profile, _, err := cfg.GetProfile(profileName)
+		if err != nil {
+			return err
+		}
+
+		wsPath := mangrove.GetWorkspacePath(cfg, profileName, wsName)
+		meta, err := mangrove.LoadWorkspaceMetadata(wsPath)
+		if err != nil {
+			return err
+		}
+		wsRepos := mangrove.WorkspaceRepos(meta, profile)
+
+		var repos []mangrove.Repo
+		switch {
+		case len(repoArgs) > 0:
+			for _, name := range repoArgs {
+				repo := mangrove.FindRepo(wsRepos, name)
+				if repo == nil {
+					return fmt.Errorf("repo %q is not part of workspace %q", name, wsName)
+				}
+				repos = append(repos, *repo)
+			}
+		case interactive:
+			names, err := mangrove.SelectRepos(mangrove.RepoNames(wsRepos))
+			if err != nil {
+				return err
+			}
+			repos = mangrove.FilterReposByName(wsRepos, names)
+		default:
+			return fmt.Errorf("repo name is required in non-interactive mode")
+		}
+
+		if len(repos) == len(wsRepos) {
+			return fmt.Errorf("cannot drop every repo from a workspace. Use mgv rm %s to remove it", wsName)
+		}
+
+		// Ask about branch deletion in interactive mode
+		if interactive && !dropRepoWithBranch {
+			fmt.Fprint(os.Stderr, "? Also delete local branches? (y/N): ")
+			reader := bufio.NewReader(os.Stdin)
+			input, _ := reader.ReadString('\n')
+			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(input)), "y") {
+				dropRepoWithBranch = true
+			}
+		}
+
+		return mangrove.RemoveRepos(cfg, profile, profileName, wsName, repos, dropRepoWithBranch, dropRepoForce)
+	},
+}
+
+func init() {
+	dropRepoCmd.Flags().BoolVarP(&dropRepoYes, "yes", "y", false, "non-interactive mode (skip confirmations)")
+	dropRepoCmd.Flags().BoolVar(&dropRepoWithBranch, "with-branch", false, "also delete local branches")
+	dropRepoCmd.Flags().BoolVarP(&dropRepoForce, "force", "f", false, "force remove even with uncommitted changes or an unfinished sync or apply")
+	rootCmd.AddCommand(dropRepoCmd)
+}
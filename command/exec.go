@@ -3,22 +3,37 @@ package command
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 
 	"github.com/Koutaro-Hanabusa/mangrove"
 	"github.com/spf13/cobra"
 )
 
+var (
+	// execParallel is the --parallel flag. 1 runs serially; 0 uses the configured parallelism.
+	execParallel int
+	execFilter   repoFilter
+)
+
 var execCmd = &cobra.Command{
 	Use:   "exec [workspace-name] -- <command> [args...]",
 	Short: "Execute a command in each repo of a workspace",
 	Long: `Execute a command in each repo worktree of a workspace.
 
+By default the command runs in one repo at a time with stdin attached.
+With --parallel it runs in as many repos at once as the configured parallel
+allows, or in up to N with --parallel=N, and every output line is prefixed with
+the repo name. Give N with "=": --parallel 2 would read 2 as the workspace name.
+A summary of exit codes and durations is printed at the end, and mgv exits
+non-zero if the command failed in any repo.
+
 Examples:
   mgv exec -- git status
   mgv exec feature-login -- git status
-  mgv exec feature-login --profile project-a -- make build`,
+  mgv exec feature-login --profile project-a -- make build
+  mgv exec feature-login --parallel -- make test
+  mgv exec feature-login --parallel=2 -- make test
+  mgv exec feature-login --repo 'web-*' --skip-repo tag:legacy -- npm test`,
 	DisableFlagParsing: false,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Split args at "--"
@@ -45,41 +60,13 @@ Examples:
 			return fmt.Errorf("no command specified. Use: mgv exec [workspace] -- <command>")
 		}
 
-		var profileName, wsName string
-
+		var wsArgs []string
 		if wsNameArg != "" {
-			wsName = wsNameArg
-			_, pName, err := resolveProfile(profileFlag == "")
-			if err != nil {
-				return err
-			}
-			profileName = pName
-		} else {
-			// Interactive workspace selection
-			if !mangrove.IsFzfAvailable() {
-				return fmt.Errorf("fzf is required for interactive mode. Install with: brew install fzf")
-			}
-
-			workspaces, err := mangrove.ListWorkspaces(cfg, profileFlag)
-			if err != nil {
-				return err
-			}
-			if len(workspaces) == 0 {
-				return fmt.Errorf("no workspaces found")
-			}
-
-			labels := mangrove.WorkspaceLabels(workspaces)
-			selected, err := mangrove.SelectWorkspace(labels)
-			if err != nil {
-				return err
-			}
-
-			pName, wName, err := mangrove.ParseWorkspaceLabel(selected)
-			if err != nil {
-				return err
-			}
-			profileName = pName
-			wsName = wName
+			wsArgs = []string{wsNameArg}
+		}
+		profileName, wsName, err := resolveWorkspace(wsArgs)
+		if err != nil {
+			return err
 		}
 
 		profile, _, err := cfg.GetProfile(profileName)
@@ -88,32 +75,46 @@ Examples:
 		}
 
 		wsPath := mangrove.GetWorkspacePath(cfg, profileName, wsName)
+		repos, _, err := workspaceRepos(profile, wsPath, &execFilter)
+		if err != nil {
+			return err
+		}
 
-		// Execute command in each repo worktree
-		for _, repo := range profile.Repos {
+		var targets []mangrove.ExecTarget
+		for _, repo := range repos {
 			repoDir := filepath.Join(wsPath, repo.Name)
 			if _, err := os.Stat(repoDir); os.IsNotExist(err) {
 				mangrove.PrintWarning("Skipping %s: directory not found", repo.Name)
 				continue
 			}
+			targets = append(targets, mangrove.ExecTarget{Name: repo.Name, Dir: repoDir})
+		}
 
-			fmt.Fprintf(os.Stderr, "\n[%s]\n", mangrove.RepoNameStyle.Render(repo.Name))
-
-			execCmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
-			execCmd.Dir = repoDir
-			execCmd.Stdout = os.Stdout
-			execCmd.Stderr = os.Stderr
-			execCmd.Stdin = os.Stdin
-
-			if err := execCmd.Run(); err != nil {
-				mangrove.PrintError("Command failed in %s: %v", repo.Name, err)
+		// Execute command in each repo worktree
+		var results []mangrove.ExecResult
+		if execParallel == 1 {
+			results = mangrove.ExecSerial(targets, cmdArgs)
+		} else {
+			limit := execParallel
+			if limit <= 0 {
+				limit = cfg.GetParallelism()
 			}
+			results = mangrove.ExecParallel(targets, cmdArgs, limit, os.Stdout, os.Stderr)
 		}
 
+		mangrove.PrintExecSummary(results)
+		fmt.Fprintln(os.Stderr)
+
+		if failed := mangrove.CountFailed(results); failed > 0 {
+			return fmt.Errorf("command failed in %d of %d repos", failed, len(results))
+		}
 		return nil
 	},
 }
 
 func init() {
+	execCmd.Flags().IntVar(&execParallel, "parallel", 1, "run in up to N repos at once (--parallel alone uses the configured parallel)")
+	execCmd.Flags().Lookup("parallel").NoOptDefVal = "0"
+	execFilter.addFlags(execCmd)
 	rootCmd.AddCommand(execCmd)
 }
@@ -0,0 +1,56 @@
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var exportOutput string
+
+var exportCmd = &cobra.Command{
+	Use:   "export [workspace-name] -o <dir>",
+	Short: "Export a workspace as a multi-repo patch series",
+	Long: `Write git format-patch output for each repo's commits since its base into
+<dir>/<repo>/, plus a manifest.yaml mapping the patches to profile repo names.
+Uncommitted changes are not exported.
+
+Use mgv import on another machine to recreate the workspace from the directory,
+without access to the remotes.
+
+Examples:
+  mgv export -o /tmp/feature-login
+  mgv export feature-login -o /tmp/feature-login`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profileName, wsName, err := resolveWorkspace(args)
+		if err != nil {
+			return err
+		}
+
+		profile, _, err := cfg.GetProfile(profileName)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "\nExporting workspace: %s/%s\n", profileName, wsName)
+		manifest, err := mangrove.ExportWorkspace(cfg, profile, profileName, wsName, exportOutput)
+		if err != nil {
+			return err
+		}
+
+		if machineOutput() {
+			return writeOutput(manifest)
+		}
+		fmt.Fprintf(os.Stderr, "\nExported to: %s\n", exportOutput)
+		return nil
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOutput, "output-dir", "o", "", "directory to write the patches and manifest to")
+	_ = exportCmd.MarkFlagRequired("output-dir")
+	rootCmd.AddCommand(exportCmd)
+}
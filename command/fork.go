@@ -0,0 +1,66 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var forkWithChanges bool
+
+var forkCmd = &cobra.Command{
+	Use:   "fork [source] [new-name]",
+	Short: "Create a new workspace from an existing one",
+	Long: `Create a new workspace whose branches start at the current HEAD of each repo
+in the source workspace, for trying an alternative without touching the original.
+
+The new branches are named by branch_template and keep the source's base branches.
+The source workspace is recorded as the parent in .mgv.yaml.
+Use --with-changes to also copy uncommitted changes (including untracked files);
+the source keeps its changes.
+
+Examples:
+  mgv fork
+  mgv fork feature-login feature-login-alt
+  mgv fork feature-login feature-login-alt --with-changes`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profileName, srcName, err := resolveWorkspace(args[:min(len(args), 1)])
+		if err != nil {
+			return err
+		}
+
+		var dstName string
+		if len(args) > 1 {
+			dstName = args[1]
+		} else {
+			defaultName := srcName + "-fork"
+			fmt.Fprintf(os.Stderr, "? New workspace name (%s): ", defaultName)
+			reader := bufio.NewReader(os.Stdin)
+			input, err := reader.ReadString('\n')
+			if err != nil {
+				return fmt.Errorf("failed to read workspace name: %w", err)
+			}
+			dstName = strings.TrimSpace(input)
+			if dstName == "" {
+				dstName = defaultName
+			}
+		}
+
+		profile, _, err := cfg.GetProfile(profileName)
+		if err != nil {
+			return err
+		}
+
+		return mangrove.ForkWorkspace(cfg, profile, profileName, srcName, dstName, forkWithChanges)
+	},
+}
+
+func init() {
+	forkCmd.Flags().BoolVar(&forkWithChanges, "with-changes", false, "copy uncommitted changes from the source workspace")
+	rootCmd.AddCommand(forkCmd)
+}
@@ -0,0 +1,137 @@
+package command
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gcYes        bool
+	gcDryRun     bool
+	gcMerged     bool
+	gcOlderThan  int
+	gcWithBranch bool
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove finished and stale workspaces",
+	Long: `Find workspaces that are no longer needed and remove them.
+
+A workspace is reported if every repo worktree is clean and either:
+  merged  every branch is fully merged into its base (locally or on origin),
+          including commits that were cherry-picked or rebased onto the base
+  idle    there has been no commit for --older-than days
+
+Workspaces with uncommitted changes, missing worktrees or a sync in progress are
+never reported. Removal runs the usual pre_remove / post_remove hooks.
+
+Examples:
+  mgv gc --dry-run
+  mgv gc
+  mgv gc --older-than 0           # merged workspaces only
+  mgv gc --merged=false --older-than 60
+  mgv gc --yes --with-branch`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if gcOlderThan < 0 {
+			return fmt.Errorf("--older-than must be 0 or more days")
+		}
+		opts := mangrove.GCOptions{
+			Merged:  gcMerged,
+			IdleFor: time.Duration(gcOlderThan) * 24 * time.Hour,
+		}
+		if !opts.Merged && opts.IdleFor == 0 {
+			return fmt.Errorf("no criteria: use --merged or --older-than")
+		}
+
+		candidates, err := mangrove.FindGCCandidates(cfg, profileFlag, opts)
+		if err != nil {
+			return err
+		}
+
+		if machineOutput() {
+			if candidates == nil {
+				candidates = []mangrove.GCCandidate{}
+			}
+			if err := writeOutput(candidates); err != nil {
+				return err
+			}
+		} else {
+			printGCCandidates(candidates)
+		}
+
+		if len(candidates) == 0 || gcDryRun {
+			return nil
+		}
+
+		if !gcYes {
+			fmt.Fprintf(os.Stderr, "? Remove %d workspace(s)? (y/N): ", len(candidates))
+			reader := bufio.NewReader(os.Stdin)
+			input, _ := reader.ReadString('\n')
+			if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(input)), "y") {
+				return mangrove.ErrCancelled
+			}
+		}
+
+		var errs []error
+		for _, c := range candidates {
+			profile, _, err := cfg.GetProfile(c.Profile)
+			if err == nil {
+				err = mangrove.RemoveWorkspace(cfg, profile, c.Profile, c.Workspace, gcWithBranch, false)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s/%s: %w", c.Profile, c.Workspace, err))
+			}
+		}
+		return errors.Join(errs...)
+	},
+}
+
+// printGCCandidates prints each candidate with why it was selected.
+func printGCCandidates(candidates []mangrove.GCCandidate) {
+	fmt.Fprintln(os.Stderr)
+	if len(candidates) == 0 {
+		fmt.Fprintln(os.Stderr, "No workspaces to remove.")
+		fmt.Fprintln(os.Stderr)
+		return
+	}
+
+	now := time.Now()
+	for _, c := range candidates {
+		fmt.Fprintf(os.Stderr, "  %-30s %-14s %s\n",
+			c.Profile+"/"+c.Workspace,
+			strings.Join(c.Reasons, ", "),
+			mangrove.DimStyle.Render("last commit "+formatAge(now.Sub(c.LastActive))+" ago"),
+		)
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+// formatAge formats a duration in days, hours or minutes.
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
+
+func init() {
+	gcCmd.Flags().BoolVarP(&gcYes, "yes", "y", false, "remove without confirmation")
+	gcCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "only list the workspaces that would be removed")
+	gcCmd.Flags().BoolVar(&gcMerged, "merged", true, "select workspaces whose branches are merged into their base")
+	gcCmd.Flags().IntVar(&gcOlderThan, "older-than", 30, "select clean workspaces without a commit for this many days (0 to disable)")
+	gcCmd.Flags().BoolVar(&gcWithBranch, "with-branch", false, "also delete local branches")
+	rootCmd.AddCommand(gcCmd)
+}
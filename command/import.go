@@ -0,0 +1,58 @@
+package command
+
+import (
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var importName string
+
+var importCmd = &cobra.Command{
+	Use:   "import <dir>",
+	Short: "Create a workspace from an exported patch series",
+	Long: `Create a new workspace from a directory written by mgv export and apply each
+repo's patches with git am.
+
+Repos are matched to the profile by name. The profile recorded in the export is
+used if it exists, unless --profile is given. The workspace keeps the exported
+name unless --name is given. If a series does not apply, git am is aborted in
+that repo and the workspace is kept so the patches can be applied by hand.
+
+Examples:
+  mgv import /tmp/feature-login
+  mgv import /tmp/feature-login --name review-login --profile project-a`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := args[0]
+		manifest, err := mangrove.LoadExportManifest(dir)
+		if err != nil {
+			return err
+		}
+
+		name := importName
+		if name == "" {
+			name = manifest.Workspace
+		}
+		if err := mangrove.ValidateWorkspaceName(name); err != nil {
+			return err
+		}
+
+		var profile *mangrove.Profile
+		var profileName string
+		if _, ok := cfg.Profiles[manifest.Profile]; ok && profileFlag == "" {
+			profile, profileName, err = cfg.GetProfile(manifest.Profile)
+		} else {
+			profile, profileName, err = resolveProfile(true)
+		}
+		if err != nil {
+			return err
+		}
+
+		return mangrove.ImportWorkspace(cfg, profile, profileName, name, dir)
+	},
+}
+
+func init() {
+	importCmd.Flags().StringVarP(&importName, "name", "n", "", "workspace name (default: the exported name)")
+	rootCmd.AddCommand(importCmd)
+}
@@ -16,7 +16,7 @@ import (
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize a new mgv configuration",
-	Long:  "Interactively create a new ~/.config/mgv/config.yaml configuration file.",
+	Long:  "Interactively create a new personal configuration file ($MGV_CONFIG, or config.yaml in $XDG_CONFIG_HOME/mgv or ~/.config/mgv).",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		reader := bufio.NewReader(os.Stdin)
 
@@ -25,7 +25,10 @@ var initCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("cannot determine home directory: %w", err)
 		}
-		configPath := filepath.Join(home, ".config", "mgv", "config.yaml")
+		configPath, err := mangrove.ConfigPath()
+		if err != nil {
+			return err
+		}
 		if _, err := os.Stat(configPath); err == nil {
 			fmt.Fprintf(os.Stderr, "? Config already exists at %s. Overwrite? (y/N): ", configPath)
 			if !promptYesNo(reader, false) {
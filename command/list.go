@@ -10,17 +10,36 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var listArchived bool
+
 var listCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls"},
 	Short:   "List all workspaces",
-	Long:    "List all workspaces with their status (clean/changed).",
+	Long:    "List all workspaces with their status (clean/changed).\nUse --archived to list workspaces archived with mgv archive instead.",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if listArchived {
+			return listArchives()
+		}
+
 		workspaces, err := mangrove.ListWorkspaces(cfg, profileFlag)
 		if err != nil {
 			return err
 		}
 
+		if machineOutput() {
+			sort.Slice(workspaces, func(i, j int) bool {
+				if workspaces[i].ProfileName != workspaces[j].ProfileName {
+					return workspaces[i].ProfileName < workspaces[j].ProfileName
+				}
+				return workspaces[i].WorkspaceName < workspaces[j].WorkspaceName
+			})
+			if workspaces == nil {
+				workspaces = []mangrove.WorkspaceInfo{}
+			}
+			return writeOutput(workspaces)
+		}
+
 		if len(workspaces) == 0 {
 			fmt.Fprintln(os.Stderr, "No workspaces found.")
 			return nil
@@ -68,6 +87,46 @@ var listCmd = &cobra.Command{
 	},
 }
 
+// listArchives prints the archived workspaces grouped by profile.
+func listArchives() error {
+	records, err := mangrove.ListArchives(profileFlag)
+	if err != nil {
+		return err
+	}
+
+	if machineOutput() {
+		if records == nil {
+			records = []mangrove.ArchiveRecord{}
+		}
+		return writeOutput(records)
+	}
+
+	if len(records) == 0 {
+		fmt.Fprintln(os.Stderr, "No archived workspaces found.")
+		return nil
+	}
+
+	// Records are sorted by profile, then name
+	for i, r := range records {
+		if i == 0 || records[i-1].Profile != r.Profile {
+			fmt.Fprintf(os.Stderr, "\n%s:\n", mangrove.ProfileNameStyle.Render(r.Profile))
+		}
+		branches := make([]string, len(r.Repos))
+		for j, ar := range r.Repos {
+			branches[j] = fmt.Sprintf("[%s: %s]", ar.Name, ar.Branch)
+		}
+		fmt.Fprintf(os.Stderr, "  %-20s %s %s\n",
+			r.Name,
+			strings.Join(branches, " "),
+			mangrove.DimStyle.Render("archived "+r.ArchivedAt.Local().Format("2006-01-02 15:04")),
+		)
+	}
+
+	fmt.Fprintln(os.Stderr)
+	return nil
+}
+
 func init() {
+	listCmd.Flags().BoolVar(&listArchived, "archived", false, "list archived workspaces")
 	rootCmd.AddCommand(listCmd)
 }
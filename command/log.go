@@ -0,0 +1,55 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logPatch  bool
+	logFilter repoFilter
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log [workspace-name]",
+	Short: "Show the commits in every repo of a workspace",
+	Long: `Show the commits on each repo's workspace branch since its base branch,
+grouped under a header per repo.
+
+With --patch every commit is shown in full with its diff, paths prefixed with
+the repo name (a/<repo>/<file>), as a single stream without repo headers.
+Output goes through $PAGER when stdout is a terminal.
+
+Examples:
+  mgv log
+  mgv log feature-login
+  mgv log feature-login --patch`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targets, err := diffTargets(args, &logFilter)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		changed, err := mangrove.WriteLog(&buf, targets, logPatch, usePager())
+		if err != nil {
+			return err
+		}
+		if changed == 0 {
+			fmt.Fprintln(os.Stderr, "No commits since the base.")
+			return nil
+		}
+		return page(buf.Bytes())
+	},
+}
+
+func init() {
+	logCmd.Flags().BoolVar(&logPatch, "patch", false, "show each commit with its diff, paths prefixed with the repo name")
+	logFilter.addFlags(logCmd)
+	rootCmd.AddCommand(logCmd)
+}
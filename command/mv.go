@@ -0,0 +1,67 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var mvCmd = &cobra.Command{
+	Use:     "mv [old-name] [new-name]",
+	Aliases: []string{"rename"},
+	Short:   "Rename a workspace and its branches",
+	Long: `Rename a workspace.
+
+Branches named after the workspace (by branch_template) are renamed in every repo,
+the worktrees are moved with git worktree move, and the directory is renamed.
+Branches that were checked out from existing branches keep their names.
+If any repo fails, for example because the target branch already exists,
+every step is rolled back.
+
+Interactive mode: presents a list of workspaces and prompts for the new name.
+
+Examples:
+  mgv mv
+  mgv mv feature-login feature-auth`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profileName, oldName, err := resolveWorkspace(args[:min(len(args), 1)])
+		if err != nil {
+			return err
+		}
+
+		var newName string
+		if len(args) > 1 {
+			newName = args[1]
+		} else {
+			fmt.Fprintf(os.Stderr, "? New name for %s: ", oldName)
+			reader := bufio.NewReader(os.Stdin)
+			input, err := reader.ReadString('\n')
+			if err != nil {
+				return fmt.Errorf("failed to read workspace name: %w", err)
+			}
+			newName = strings.TrimSpace(input)
+		}
+		if newName == "" {
+			return fmt.Errorf("new workspace name is required")
+		}
+		if err := mangrove.ValidateWorkspaceName(newName); err != nil {
+			return err
+		}
+
+		profile, _, err := cfg.GetProfile(profileName)
+		if err != nil {
+			return err
+		}
+
+		return mangrove.RenameWorkspace(cfg, profile, profileName, oldName, newName)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mvCmd)
+}
@@ -11,8 +11,10 @@ import (
 )
 
 var (
-	newYes  bool
-	newBase string
+	newYes      bool
+	newBase     string
+	newCheckout string
+	newFilter   repoFilter
 )
 
 var newCmd = &cobra.Command{
@@ -20,8 +22,16 @@ var newCmd = &cobra.Command{
 	Short: "Create a new workspace",
 	Long: `Create a new workspace with worktrees for all repos in the selected profile.
 
-Interactive mode: prompts for profile, workspace name, and base branch for each repo.
-Non-interactive mode (--yes): uses default_profile and default_base for each repo.`,
+Interactive mode: prompts for profile, new or existing branch, workspace name, and base branch for each repo.
+New branches are named by branch_template (default: the workspace name).
+Non-interactive mode (--yes): uses default_profile and default_base for each repo.
+
+Use --checkout to work on an existing branch instead of creating a new one. Each repo
+attaches the local branch if it exists, tracks origin/<branch> if it only exists on the
+remote, and otherwise creates the branch from its base.
+
+Use --repo / --skip-repo (or --pick-repos) to create a workspace with only some of
+the profile's repos. Later commands only act on the repos the workspace contains.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		interactive := !newYes
@@ -32,12 +42,41 @@ Non-interactive mode (--yes): uses default_profile and default_base for each rep
 			return err
 		}
 
+		if interactive && !mangrove.IsFzfAvailable() {
+			return fmt.Errorf("fzf is required for interactive mode. Install with: brew install fzf\nOr use --yes flag for non-interactive mode")
+		}
+
+		// Narrow the workspace down to the selected repos
+		if newFilter.isSet() {
+			repos, err := newFilter.selectRepos(profile.Repos)
+			if err != nil {
+				return err
+			}
+			subset := *profile
+			subset.Repos = repos
+			profile = &subset
+		}
+
+		// Choose between a new branch and an existing one
+		checkout := newCheckout
+		if checkout == "" && interactive {
+			checkout, err = selectCheckout(profile.Repos)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Get workspace name
 		var wsName string
+		defaultName := strings.ReplaceAll(checkout, "/", "-")
 		if len(args) > 0 {
 			wsName = args[0]
 		} else if interactive {
-			fmt.Fprint(os.Stderr, "? Workspace name: ")
+			if defaultName != "" {
+				fmt.Fprintf(os.Stderr, "? Workspace name (%s): ", defaultName)
+			} else {
+				fmt.Fprint(os.Stderr, "? Workspace name: ")
+			}
 			reader := bufio.NewReader(os.Stdin)
 			input, err := reader.ReadString('\n')
 			if err != nil {
@@ -45,45 +84,73 @@ Non-interactive mode (--yes): uses default_profile and default_base for each rep
 			}
 			wsName = strings.TrimSpace(input)
 		}
+		if wsName == "" {
+			wsName = defaultName
+		}
 
 		if wsName == "" {
 			return fmt.Errorf("workspace name is required")
 		}
 
-		// Determine base branches for each repo
-		baseBranches := make(map[string]string)
+		sources, err := worktreeSources(profile.Repos, interactive, newBase, checkout)
+		if err != nil {
+			return err
+		}
 
-		if interactive {
-			// Check fzf availability
-			if !mangrove.IsFzfAvailable() {
-				return fmt.Errorf("fzf is required for interactive mode. Install with: brew install fzf\nOr use --yes flag for non-interactive mode")
-			}
+		return mangrove.CreateWorkspace(cfg, profile, profileName, wsName, sources)
+	},
+}
 
-			for _, repo := range profile.Repos {
-				prompt := fmt.Sprintf("[%s] Base branch:", repo.Name)
-				branch, err := mangrove.SelectBranch(repo.Path, prompt, repo.GetDefaultBase())
-				if err != nil {
-					return fmt.Errorf("branch selection for %s failed: %w", repo.Name, err)
-				}
-				baseBranches[repo.Name] = branch
-			}
-		} else {
-			// Non-interactive: use --base or default_base for each repo
-			for _, repo := range profile.Repos {
-				if newBase != "" {
-					baseBranches[repo.Name] = newBase
-				} else {
-					baseBranches[repo.Name] = repo.GetDefaultBase()
-				}
+// selectCheckout asks whether to create a new branch or check out an existing one,
+// and returns the branch to check out (empty for a new branch).
+func selectCheckout(repos []mangrove.Repo) (string, error) {
+	mode, err := mangrove.SelectWithFzf([]string{"new", "checkout"}, "Branch:",
+		"new=新しいブランチを作成 / checkout=既存またはリモートのブランチを使用")
+	if err != nil {
+		return "", err
+	}
+	if mode != "checkout" {
+		return "", nil
+	}
+	candidates := mangrove.CheckoutCandidates(repos)
+	return mangrove.SelectWithFzf(candidates, "Checkout branch:", "Select branch to check out")
+}
+
+// worktreeSources determines the branch source for each repo from --base and
+// --checkout, asking for a base branch per repo in interactive mode.
+func worktreeSources(repos []mangrove.Repo, interactive bool, base, checkout string) (map[string]mangrove.WorktreeSource, error) {
+	sources := make(map[string]mangrove.WorktreeSource)
+	for _, repo := range repos {
+		repoBase := repo.GetDefaultBase()
+		if base != "" {
+			repoBase = base
+		}
+
+		// An empty branch is resolved from branch_template by CreateWorkspace
+		src := mangrove.WorktreeSource{Base: repoBase, Mode: mangrove.CheckoutNew}
+		if checkout != "" {
+			src = mangrove.ResolveCheckout(repo.Path, checkout, repoBase)
+		}
+
+		// Only branches created from the base need a base selection
+		if interactive && base == "" && src.Mode == mangrove.CheckoutNew {
+			prompt := fmt.Sprintf("[%s] Base branch:", repo.Name)
+			branch, err := mangrove.SelectBranch(repo.Path, prompt, repo.GetDefaultBase())
+			if err != nil {
+				return nil, fmt.Errorf("branch selection for %s failed: %w", repo.Name, err)
 			}
+			src.Base = branch
 		}
 
-		return mangrove.CreateWorkspace(cfg, profile, profileName, wsName, baseBranches)
-	},
+		sources[repo.Name] = src
+	}
+	return sources, nil
 }
 
 func init() {
 	newCmd.Flags().BoolVarP(&newYes, "yes", "y", false, "non-interactive mode (use defaults)")
 	newCmd.Flags().StringVarP(&newBase, "base", "b", "", "common base branch for all repos")
+	newCmd.Flags().StringVarP(&newCheckout, "checkout", "c", "", "check out an existing local or remote branch instead of creating one")
+	newFilter.addFlags(newCmd)
 	rootCmd.AddCommand(newCmd)
 }
@@ -0,0 +1,43 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+)
+
+// defaultPager is used when $PAGER is not set. -F quits if the output fits on
+// one screen, -R keeps colors and -X leaves the output on the screen.
+const defaultPager = "less -FRX"
+
+// usePager reports whether output should go through the pager.
+func usePager() bool {
+	return mangrove.IsTerminal(os.Stdout)
+}
+
+// page writes data through $PAGER when stdout is a terminal, or straight to stdout otherwise.
+func page(data []byte) error {
+	pager := strings.TrimSpace(os.Getenv("PAGER"))
+	if pager == "" {
+		pager = defaultPager
+	}
+	if !usePager() || pager == "cat" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = strings.NewReader(string(data))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("pager %q failed: %w", pager, err)
+	}
+	return nil
+}
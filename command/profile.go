@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/Koutaro-Hanabusa/mangrove"
 	"github.com/spf13/cobra"
@@ -38,11 +39,15 @@ var profileListCmd = &cobra.Command{
 			}
 			profile := cfg.Profiles[name]
 			repoCount := len(profile.Repos)
-			fmt.Fprintf(os.Stderr, " %s %s  (%d repos)\n",
+			fmt.Fprintf(os.Stderr, " %s %s  (%d repos)",
 				marker,
 				mangrove.ProfileNameStyle.Render(name),
 				repoCount,
 			)
+			if source := cfg.ProfileSource(name); source != "" {
+				fmt.Fprintf(os.Stderr, "  %s", mangrove.DimStyle.Render("from "+mangrove.CollapsePath(source)))
+			}
+			fmt.Fprintln(os.Stderr)
 		}
 		fmt.Fprintln(os.Stderr)
 		return nil
@@ -60,25 +65,59 @@ var profileShowCmd = &cobra.Command{
 			return fmt.Errorf("profile %q not found", name)
 		}
 
+		if machineOutput() {
+			info := mangrove.NewProfileInfo(name, profile, name == cfg.DefaultProfile)
+			info.Source = cfg.ProfileSource(name)
+			return writeOutput(info)
+		}
+
 		fmt.Fprintf(os.Stderr, "\n%s", mangrove.ProfileNameStyle.Render(name))
 		if name == cfg.DefaultProfile {
 			fmt.Fprintf(os.Stderr, " %s", mangrove.DimStyle.Render("(default)"))
 		}
 		fmt.Fprintln(os.Stderr)
 
+		if source := cfg.ProfileSource(name); source != "" {
+			fmt.Fprintf(os.Stderr, "\n  source: %s\n", mangrove.CollapsePath(source))
+		}
+
+		if profile.BranchTemplate != "" {
+			fmt.Fprintf(os.Stderr, "\n  branch_template: %s\n", mangrove.BranchNameStyle.Render(profile.BranchTemplate))
+		}
+
 		fmt.Fprintf(os.Stderr, "\n  %s\n", mangrove.HeaderStyle.Render("Repositories"))
 		for _, repo := range profile.Repos {
 			defaultBase := repo.GetDefaultBase()
 			fmt.Fprintf(os.Stderr, "    %s\n", mangrove.RepoNameStyle.Render(repo.Name))
 			fmt.Fprintf(os.Stderr, "      path:         %s\n", repo.Path)
 			fmt.Fprintf(os.Stderr, "      default_base: %s\n", mangrove.BranchNameStyle.Render(defaultBase))
+			if repo.BranchTemplate != "" {
+				fmt.Fprintf(os.Stderr, "      branch_template: %s\n", mangrove.BranchNameStyle.Render(repo.BranchTemplate))
+			}
+			if len(repo.Tags) > 0 {
+				fmt.Fprintf(os.Stderr, "      tags:         %s\n", strings.Join(repo.Tags, ", "))
+			}
 		}
 
-		if len(profile.Hooks.PostCreate) > 0 {
-			fmt.Fprintf(os.Stderr, "\n  %s\n", mangrove.HeaderStyle.Render("Hooks (post_create)"))
-			for _, hook := range profile.Hooks.PostCreate {
+		for _, stage := range []string{
+			mangrove.HookPreCreate,
+			mangrove.HookPostCreate,
+			mangrove.HookPreRemove,
+			mangrove.HookPostRemove,
+			mangrove.HookPostApply,
+		} {
+			hooks := profile.Hooks.ForStage(stage)
+			if len(hooks) == 0 {
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "\n  %s\n", mangrove.HeaderStyle.Render(fmt.Sprintf("Hooks (%s)", stage)))
+			for _, hook := range hooks {
+				target := hook.Repo
+				if target == "" {
+					target = "(workspace)"
+				}
 				fmt.Fprintf(os.Stderr, "    %s: %s\n",
-					mangrove.RepoNameStyle.Render(hook.Repo),
+					mangrove.RepoNameStyle.Render(target),
 					mangrove.DimStyle.Render(hook.Run),
 				)
 			}
@@ -181,7 +220,7 @@ var profileAddCmd = &cobra.Command{
 		if cfg.DefaultProfile == "" {
 			fmt.Fprint(os.Stderr, "? Set as default profile? (Y/n): ")
 			if promptYesNo(reader, true) {
-				cfg.DefaultProfile = profileName
+				cfg.SetDefaultProfile(profileName)
 			}
 		}
 
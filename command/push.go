@@ -0,0 +1,103 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pushForceWithLease bool
+	pushRemote         string
+	pushFilter         repoFilter
+)
+
+var pushCmd = &cobra.Command{
+	Use:   "push [workspace-name]",
+	Short: "Push every repo's workspace branch",
+	Long: `Push the branch of each repo in a workspace with --set-upstream.
+
+Branches go to their configured remote, or origin if none is set (--remote
+overrides both). Repos with nothing ahead of their upstream, or of their base
+branch when they have never been pushed, are skipped. A summary of what was
+pushed is printed at the end, and mgv exits non-zero if any push failed.
+
+Use --force-with-lease after rebasing (for example with mgv sync).
+
+Examples:
+  mgv push
+  mgv push feature-login
+  mgv push feature-login --force-with-lease
+  mgv push feature-login --remote upstream --repo api`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profileName, wsName, err := resolveWorkspace(args)
+		if err != nil {
+			return err
+		}
+
+		profile, _, err := cfg.GetProfile(profileName)
+		if err != nil {
+			return err
+		}
+
+		wsPath := mangrove.GetWorkspacePath(cfg, profileName, wsName)
+		repos, meta, err := workspaceRepos(profile, wsPath, &pushFilter)
+		if err != nil {
+			return err
+		}
+
+		var targets []mangrove.PushTarget
+		for _, repo := range repos {
+			repoDir := filepath.Join(wsPath, repo.Name)
+			if _, err := os.Stat(repoDir); os.IsNotExist(err) {
+				mangrove.PrintWarning("Skipping %s: directory not found", repo.Name)
+				continue
+			}
+			branch, err := mangrove.CurrentBranch(repoDir)
+			if err != nil {
+				return fmt.Errorf("%s: %w", repo.Name, err)
+			}
+			if branch == "HEAD" {
+				mangrove.PrintWarning("Skipping %s: detached HEAD", repo.Name)
+				continue
+			}
+			targets = append(targets, mangrove.PushTarget{
+				Name:   repo.Name,
+				Dir:    repoDir,
+				Branch: branch,
+				Base:   meta.RepoBase(&repo),
+				Remote: pushRemote,
+			})
+		}
+
+		results := mangrove.PushRepos(targets, pushForceWithLease, cfg.GetParallelism())
+
+		if machineOutput() {
+			if results == nil {
+				results = []mangrove.PushResult{}
+			}
+			if err := writeOutput(results); err != nil {
+				return err
+			}
+		} else {
+			mangrove.PrintPushSummary(results)
+			fmt.Fprintln(os.Stderr)
+		}
+
+		if failed := mangrove.CountFailed(results); failed > 0 {
+			return fmt.Errorf("push failed in %d of %d repos", failed, len(results))
+		}
+		return nil
+	},
+}
+
+func init() {
+	pushCmd.Flags().BoolVar(&pushForceWithLease, "force-with-lease", false, "overwrite the remote branch if it is where we last saw it")
+	pushCmd.Flags().StringVar(&pushRemote, "remote", "", "remote to push to (default: the branch's remote, or origin)")
+	pushFilter.addFlags(pushCmd)
+	rootCmd.AddCommand(pushCmd)
+}
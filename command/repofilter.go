@@ -0,0 +1,65 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+// repoFilter holds the repo selection flags shared by workspace commands.
+type repoFilter struct {
+	include []string
+	exclude []string
+	pick    bool
+}
+
+// addFlags registers --repo, --skip-repo and --pick-repos on cmd.
+func (f *repoFilter) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVarP(&f.include, "repo", "r", nil, "only target repos matching a name, glob or tag:<tag> (repeatable)")
+	cmd.Flags().StringSliceVar(&f.exclude, "skip-repo", nil, "skip repos matching a name, glob or tag:<tag> (repeatable)")
+	cmd.Flags().BoolVar(&f.pick, "pick-repos", false, "choose target repos interactively with fzf")
+}
+
+// isSet reports whether any selection flag was given.
+func (f *repoFilter) isSet() bool {
+	return len(f.include) > 0 || len(f.exclude) > 0 || f.pick
+}
+
+// selectRepos narrows repos down by the flags, then lets the user pick from the
+// remaining ones with fzf when --pick-repos is set.
+func (f *repoFilter) selectRepos(repos []mangrove.Repo) ([]mangrove.Repo, error) {
+	selector := mangrove.RepoSelector{Include: f.include, Exclude: f.exclude}
+	selected, err := selector.Select(repos)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.pick && len(selected) > 0 {
+		names, err := mangrove.SelectRepos(mangrove.RepoNames(selected))
+		if err != nil {
+			return nil, err
+		}
+		selected = mangrove.FilterReposByName(selected, names)
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no repos selected")
+	}
+	return selected, nil
+}
+
+// workspaceRepos loads the metadata of a workspace and returns the repos it
+// contains, narrowed down by filter.
+func workspaceRepos(profile *mangrove.Profile, wsPath string, filter *repoFilter) ([]mangrove.Repo, *mangrove.WorkspaceMetadata, error) {
+	meta, err := mangrove.LoadWorkspaceMetadata(wsPath)
+	if err != nil {
+		mangrove.PrintWarning("%v", err)
+	}
+
+	repos, err := filter.selectRepos(mangrove.WorkspaceRepos(meta, profile))
+	if err != nil {
+		return nil, nil, err
+	}
+	return repos, meta, nil
+}
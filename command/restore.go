@@ -0,0 +1,76 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var restoreForce bool
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [workspace-name]",
+	Short: "Restore an archived workspace",
+	Long: `Recreate a workspace archived with mgv archive: each repo is checked out on
+its archived branch at the archived HEAD and uncommitted changes are re-applied.
+A deleted branch is recreated from the archive. The archive is removed once the
+workspace is restored.
+
+A branch that moved since it was archived stops the restore; use --force to
+check it out at its current tip instead.
+
+Examples:
+  mgv restore
+  mgv restore feature-login
+  mgv restore feature-login --force`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profileName, wsName, err := resolveArchive(args)
+		if err != nil {
+			return err
+		}
+
+		return mangrove.RestoreWorkspace(cfg, profileName, wsName, restoreForce)
+	},
+}
+
+// resolveArchive resolves the profile and name of an archived workspace from
+// args, or lets the user pick one with fzf.
+func resolveArchive(args []string) (profileName, wsName string, err error) {
+	if len(args) > 0 {
+		_, pName, err := resolveProfile(profileFlag == "")
+		if err != nil {
+			return "", "", err
+		}
+		return pName, args[0], nil
+	}
+
+	if !mangrove.IsFzfAvailable() {
+		return "", "", fmt.Errorf("fzf is required for interactive mode. Install with: brew install fzf")
+	}
+
+	records, err := mangrove.ListArchives(profileFlag)
+	if err != nil {
+		return "", "", err
+	}
+	if len(records) == 0 {
+		return "", "", fmt.Errorf("no archived workspaces found")
+	}
+
+	labels := make([]string, len(records))
+	for i, r := range records {
+		labels[i] = r.Label()
+	}
+	selected, err := mangrove.SelectWithFzf(labels, "Restore:", "Select archived workspace")
+	if err != nil {
+		return "", "", err
+	}
+
+	return mangrove.ParseWorkspaceLabel(selected)
+}
+
+func init() {
+	restoreCmd.Flags().BoolVarP(&restoreForce, "force", "f", false, "restore even if a branch moved since it was archived")
+	rootCmd.AddCommand(restoreCmd)
+}
@@ -14,6 +14,7 @@ var (
 	rmYes        bool
 	rmWithBranch bool
 	rmForce      bool
+	rmFilter     repoFilter
 )
 
 var rmCmd = &cobra.Command{
@@ -23,7 +24,9 @@ var rmCmd = &cobra.Command{
 
 Interactive mode: presents a list of workspaces to choose from.
 Use --with-branch to also delete the local branches.
-Use --force to remove workspaces with uncommitted changes.`,
+Use --force to remove workspaces with uncommitted changes or an unfinished
+sync or apply.
+Use --repo / --skip-repo to remove only some repos' worktrees and keep the workspace.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		interactive := !rmYes
@@ -69,10 +72,27 @@ Use --force to remove workspaces with uncommitted changes.`,
 			return err
 		}
 
+		wsPath := mangrove.GetWorkspacePath(cfg, profileName, wsName)
+		meta, err := mangrove.LoadWorkspaceMetadata(wsPath)
+		if err != nil {
+			mangrove.PrintWarning("%v", err)
+		}
+		wsRepos := mangrove.WorkspaceRepos(meta, profile)
+		repos, err := rmFilter.selectRepos(wsRepos)
+		if err != nil {
+			return err
+		}
+
+		// An unfinished sync or apply is not overridden by the uncommitted changes prompt
+		if !rmForce {
+			if err := mangrove.CheckInProgress(wsPath, wsName); err != nil {
+				return fmt.Errorf("%w, or use --force to remove anyway", err)
+			}
+		}
+
 		// Check for uncommitted changes and warn
 		if !rmForce && !rmYes {
-			wsPath := mangrove.GetWorkspacePath(cfg, profileName, wsName)
-			for _, repo := range profile.Repos {
+			for _, repo := range repos {
 				repoDir := wsPath + "/" + repo.Name
 				if _, err := os.Stat(repoDir); os.IsNotExist(err) {
 					continue
@@ -105,6 +125,10 @@ Use --force to remove workspaces with uncommitted changes.`,
 			}
 		}
 
+		// Removing a subset of the repos keeps the workspace
+		if len(repos) < len(wsRepos) {
+			return mangrove.RemoveRepos(cfg, profile, profileName, wsName, repos, rmWithBranch, rmForce)
+		}
 		return mangrove.RemoveWorkspace(cfg, profile, profileName, wsName, rmWithBranch, rmForce)
 	},
 }
@@ -112,6 +136,7 @@ Use --force to remove workspaces with uncommitted changes.`,
 func init() {
 	rmCmd.Flags().BoolVarP(&rmYes, "yes", "y", false, "non-interactive mode (skip confirmations)")
 	rmCmd.Flags().BoolVar(&rmWithBranch, "with-branch", false, "also delete local branches")
-	rmCmd.Flags().BoolVarP(&rmForce, "force", "f", false, "force remove even with uncommitted changes")
+	rmCmd.Flags().BoolVarP(&rmForce, "force", "f", false, "force remove even with uncommitted changes or an unfinished sync or apply")
+	rmFilter.addFlags(rmCmd)
 	rootCmd.AddCommand(rmCmd)
 }
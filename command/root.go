@@ -17,6 +17,9 @@ var (
 	// profileFlag is the global --profile flag.
 	profileFlag string
 
+	// outputFlag is the global --output flag.
+	outputFlag string
+
 	// cfg holds the loaded configuration.
 	cfg *mangrove.Config
 )
@@ -40,6 +43,10 @@ var rootCmd = &cobra.Command{
 	Long:    "mangrove (mgv) manages workspaces across multiple git repositories using git worktree.",
 	Version: resolveVersion(),
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := mangrove.ValidateOutputFormat(outputFlag); err != nil {
+			return err
+		}
+
 		// Skip config loading for completion commands
 		if cmd.Name() == "completion" || cmd.Name() == "help" || cmd.Name() == "init" {
 			return nil
@@ -58,10 +65,12 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&profileFlag, "profile", "p", "", "profile name (overrides default_profile)")
+	rootCmd.PersistentFlags().StringVar(&outputFlag, "output", mangrove.OutputText, "output format: text, json or yaml (json/yaml are written to stdout)")
 }
 
 // Execute runs the root command.
 func Execute() {
+	mangrove.Version = rootCmd.Version
 	if err := rootCmd.Execute(); err != nil {
 		if errors.Is(err, mangrove.ErrCancelled) {
 			return
@@ -94,3 +103,45 @@ func resolveProfile(interactive bool) (*mangrove.Profile, string, error) {
 
 	return cfg.GetProfile(profileFlag)
 }
+
+// machineOutput reports whether --output requests JSON or YAML instead of styled text.
+func machineOutput() bool {
+	return outputFlag != mangrove.OutputText
+}
+
+// writeOutput writes v to stdout in the format requested by --output.
+func writeOutput(v any) error {
+	return mangrove.WriteOutput(os.Stdout, outputFlag, v)
+}
+
+// resolveWorkspace resolves the target workspace from the first argument,
+// or lets the user pick one via fzf when no argument is given.
+func resolveWorkspace(args []string) (profileName, wsName string, err error) {
+	if len(args) > 0 {
+		_, pName, err := resolveProfile(profileFlag == "")
+		if err != nil {
+			return "", "", err
+		}
+		return pName, args[0], nil
+	}
+
+	if !mangrove.IsFzfAvailable() {
+		return "", "", fmt.Errorf("fzf is required for interactive mode. Install with: brew install fzf")
+	}
+
+	workspaces, err := mangrove.ListWorkspaces(cfg, profileFlag)
+	if err != nil {
+		return "", "", err
+	}
+	if len(workspaces) == 0 {
+		return "", "", fmt.Errorf("no workspaces found")
+	}
+
+	labels := mangrove.WorkspaceLabels(workspaces)
+	selected, err := mangrove.SelectWorkspace(labels)
+	if err != nil {
+		return "", "", err
+	}
+
+	return mangrove.ParseWorkspaceLabel(selected)
+}
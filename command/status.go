@@ -4,17 +4,22 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/Koutaro-Hanabusa/mangrove"
 	"github.com/spf13/cobra"
 )
 
+var statusFilter repoFilter
+
 var statusCmd = &cobra.Command{
 	Use:   "status [workspace-name]",
 	Short: "Show detailed git status for a workspace",
 	Long: `Show detailed git status for each repo in a workspace.
 
-Displays branch name, clean/changed status, and ahead/behind counts.`,
+Displays branch name, clean/changed status, and ahead/behind counts.
+Use --output json or --output yaml to print the workspace status to stdout.
+Use --repo / --skip-repo to show only some repos.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var profileName, wsName string
@@ -60,13 +65,31 @@ Displays branch name, clean/changed status, and ahead/behind counts.`,
 		}
 
 		wsPath := mangrove.GetWorkspacePath(cfg, profileName, wsName)
+		repos, meta, err := workspaceRepos(profile, wsPath, &statusFilter)
+		if err != nil {
+			return err
+		}
+
+		if machineOutput() {
+			info := mangrove.LoadWorkspaceInfo(cfg, profile, profileName, wsName)
+			if statusFilter.isSet() {
+				names := mangrove.RepoNames(repos)
+				info.RepoStatuses = slices.DeleteFunc(info.RepoStatuses, func(rs mangrove.RepoStatus) bool {
+					return !slices.Contains(names, rs.RepoName)
+				})
+			}
+			return writeOutput(info)
+		}
 
 		fmt.Fprintf(os.Stderr, "\n%s/%s:\n",
 			mangrove.ProfileNameStyle.Render(profileName),
 			mangrove.RepoNameStyle.Render(wsName),
 		)
+		if meta != nil && meta.Parent != "" {
+			fmt.Fprintf(os.Stderr, "  %s\n", mangrove.DimStyle.Render("forked from "+meta.Parent))
+		}
 
-		for _, repo := range profile.Repos {
+		for _, repo := range repos {
 			repoDir := filepath.Join(wsPath, repo.Name)
 
 			if _, err := os.Stat(repoDir); os.IsNotExist(err) {
@@ -86,13 +109,20 @@ Displays branch name, clean/changed status, and ahead/behind counts.`,
 				continue
 			}
 
-			ahead, behind, err := mangrove.AheadBehind(repo.Path, repo.GetDefaultBase(), branch)
+			if expected, err := mangrove.WorkspaceBranch(meta, profile, &repo, profileName, wsName); err != nil {
+				mangrove.PrintError("%s: %v", repo.Name, err)
+			} else if branch != expected {
+				mangrove.PrintWarning("%s: on branch %s, expected workspace branch %s", repo.Name, branch, expected)
+			}
+
+			base := meta.RepoBase(&repo)
+			ahead, behind, err := mangrove.AheadBehind(repo.Path, base, branch)
 			if err != nil {
 				// Non-fatal: ahead/behind may not be available
 				ahead, behind = 0, 0
 			}
 
-			mangrove.PrintRepoStatus(repo.Name, branch, changedCount, ahead, behind, repo.GetDefaultBase())
+			mangrove.PrintRepoStatus(repo.Name, branch, changedCount, ahead, behind, base)
 		}
 
 		fmt.Fprintln(os.Stderr)
@@ -101,5 +131,6 @@ Displays branch name, clean/changed status, and ahead/behind counts.`,
 }
 
 func init() {
+	statusFilter.addFlags(statusCmd)
 	rootCmd.AddCommand(statusCmd)
 }
@@ -0,0 +1,117 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Koutaro-Hanabusa/mangrove"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncStrategy string
+	syncContinue bool
+	syncAbort    bool
+	syncFilter   repoFilter
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync [workspace-name]",
+	Short: "Rebase or merge every repo in a workspace onto its base",
+	Long: `Fetch each repo and bring every worktree branch up to date with its base.
+
+The base is the branch the workspace was created from (origin/<base> is used when it exists).
+Sync stops on the first conflict. Resolve it in that repo, stage the files, then run
+--continue to resume with the remaining repos, or --abort to restore every repo.
+
+Examples:
+  mgv sync
+  mgv sync feature-login
+  mgv sync feature-login --strategy merge
+  mgv sync feature-login --repo api
+  mgv sync feature-login --continue
+  mgv sync feature-login --abort`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if syncContinue && syncAbort {
+			return fmt.Errorf("--continue and --abort cannot be used together")
+		}
+
+		profileName, wsName, err := resolveWorkspace(args)
+		if err != nil {
+			return err
+		}
+
+		profile, _, err := cfg.GetProfile(profileName)
+		if err != nil {
+			return err
+		}
+
+		wsPath := mangrove.GetWorkspacePath(cfg, profileName, wsName)
+		if _, err := os.Stat(wsPath); os.IsNotExist(err) {
+			return fmt.Errorf("workspace %q not found at %s", wsName, wsPath)
+		}
+
+		fmt.Fprintf(os.Stderr, "\nSyncing workspace: %s/%s\n",
+			mangrove.ProfileNameStyle.Render(profileName),
+			mangrove.RepoNameStyle.Render(wsName),
+		)
+
+		switch {
+		case syncAbort:
+			err = mangrove.AbortSync(wsPath)
+			if err == nil {
+				mangrove.PrintSuccess("Sync aborted")
+			}
+		case syncContinue:
+			err = mangrove.ContinueSync(wsPath)
+		default:
+			repos, meta, ferr := workspaceRepos(profile, wsPath, &syncFilter)
+			if ferr != nil {
+				return ferr
+			}
+
+			var targets []mangrove.SyncTarget
+			for _, repo := range repos {
+				repoDir := filepath.Join(wsPath, repo.Name)
+				if _, err := os.Stat(repoDir); os.IsNotExist(err) {
+					mangrove.PrintWarning("%s: worktree not found, skipping", repo.Name)
+					continue
+				}
+				targets = append(targets, mangrove.SyncTarget{
+					RepoName: repo.Name,
+					RepoPath: repo.Path,
+					Worktree: repoDir,
+					Base:     meta.RepoBase(&repo),
+				})
+			}
+
+			err = mangrove.StartSync(wsPath, targets, syncStrategy, cfg.GetParallelism())
+		}
+
+		var conflict *mangrove.SyncConflictError
+		if errors.As(err, &conflict) {
+			mangrove.PrintError("%v", conflict)
+			mangrove.PrintInfo("Resolve the conflicts in %s and stage them, then run:", conflict.Worktree)
+			mangrove.PrintInfo("  mgv sync %s --continue   (or --abort to restore every repo)", wsName)
+			fmt.Fprintln(os.Stderr)
+			return fmt.Errorf("sync stopped on conflict in %s", conflict.RepoName)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(os.Stderr)
+		return nil
+	},
+}
+
+func init() {
+	syncCmd.Flags().StringVarP(&syncStrategy, "strategy", "s", mangrove.SyncRebase, "sync strategy: rebase or merge")
+	syncCmd.Flags().BoolVar(&syncContinue, "continue", false, "resume after resolving a conflict")
+	syncCmd.Flags().BoolVar(&syncAbort, "abort", false, "abort the sync and restore every repo")
+	syncFilter.addFlags(syncCmd)
+	rootCmd.AddCommand(syncCmd)
+}
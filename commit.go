@@ -0,0 +1,75 @@
+package mangrove
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CommitTarget is a worktree to commit in, labelled by repo name.
+type CommitTarget struct {
+	Name    string
+	Dir     string
+	Message string
+	// Paths limits the commit to these files. If empty, every change is committed.
+	Paths []string
+}
+
+// CommitResult is the outcome of committing in one repo.
+type CommitResult struct {
+	RepoResult `yaml:",inline"`
+	Commit     string `json:"commit,omitempty"  yaml:"commit,omitempty"`
+	Subject    string `json:"subject,omitempty" yaml:"subject,omitempty"`
+	Files      int    `json:"files"             yaml:"files"`
+}
+
+// CommitRepos stages and commits in each target in order. Targets without changes
+// are skipped, and a failure in one repo does not stop the others.
+func CommitRepos(targets []CommitTarget) []CommitResult {
+	results := make([]CommitResult, len(targets))
+	for i, t := range targets {
+		results[i] = commitRepo(t)
+		results[i].setError()
+	}
+	return results
+}
+
+// commitRepo stages and commits the changes of a single target.
+func commitRepo(t CommitTarget) CommitResult {
+	result := CommitResult{RepoResult: RepoResult{Name: t.Name}}
+
+	if strings.TrimSpace(t.Message) == "" {
+		result.Err = fmt.Errorf("empty commit message")
+		return result
+	}
+
+	changed, err := ChangedFiles(t.Dir)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if len(changed) == 0 {
+		result.Skipped = true
+		result.Reason = "no changes"
+		return result
+	}
+
+	if err := StageAll(t.Dir, t.Paths...); err != nil {
+		result.Err = err
+		return result
+	}
+	if err := Commit(t.Dir, t.Message, t.Paths...); err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Files = len(changed)
+	if len(t.Paths) > 0 {
+		result.Files = len(t.Paths)
+	}
+	result.Subject, _, _ = strings.Cut(t.Message, "\n")
+	result.Commit, err = RevParse(t.Dir, "HEAD")
+	if err != nil {
+		result.Err = err
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package mangrove
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommitRepos(t *testing.T) {
+	api := newTestRepo(t)
+	web := newTestRepo(t)
+	docs := newTestRepo(t)
+
+	// api: a modified, a new and a deleted file, all committed
+	writeAndCommit(t, api, "old.txt", "old\n", "add old")
+	if err := os.WriteFile(filepath.Join(api, "README.md"), []byte("changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(api, "new.txt"), []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(api, "old.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	// docs: only the picked files are committed
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(docs, name), []byte(name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results := CommitRepos([]CommitTarget{
+		{Name: "api", Dir: api, Message: "shared change\n\nbody"},
+		{Name: "web", Dir: web, Message: "shared change"},
+		{Name: "docs", Dir: docs, Message: "docs only", Paths: []string{"a.txt"}},
+	})
+
+	if failed := CountFailed(results); failed != 0 {
+		t.Fatalf("CommitRepos() failed in %d repos: %+v", failed, results)
+	}
+
+	if r := results[0]; r.Skipped || r.Files != 3 || r.Subject != "shared change" {
+		t.Errorf("api result = %+v, want 3 files with subject \"shared change\"", r)
+	}
+	if head, _ := RevParse(api, "HEAD"); results[0].Commit != head {
+		t.Errorf("api commit = %s, want HEAD %s", results[0].Commit, head)
+	}
+	if count, _ := StatusChangedCount(api); count != 0 {
+		t.Errorf("api has %d changed files after commit, want 0", count)
+	}
+
+	if r := results[1]; !r.Skipped || r.Commit != "" {
+		t.Errorf("web result = %+v, want skipped", r)
+	}
+
+	if r := results[2]; r.Files != 1 {
+		t.Errorf("docs result = %+v, want 1 file", r)
+	}
+	if out := runGit(t, docs, "show", "--name-only", "--format=%s", "HEAD"); out != "docs only\n\na.txt" {
+		t.Errorf("docs HEAD = %q, want only a.txt", out)
+	}
+	if files, _ := ChangedFiles(docs); len(files) != 1 || files[0].Path != "b.txt" || files[0].Status != "??" {
+		t.Errorf("docs changed files = %+v, want untracked b.txt", files)
+	}
+}
+
+func TestCommitReposEmptyMessage(t *testing.T) {
+	dir := newTestRepo(t)
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	results := CommitRepos([]CommitTarget{{Name: "api", Dir: dir, Message: "  "}})
+	if results[0].Err == nil {
+		t.Error("CommitRepos() with an empty message should fail")
+	}
+	if count, _ := StatusChangedCount(dir); count != 1 {
+		t.Errorf("changed files = %d, want the change left uncommitted", count)
+	}
+}
@@ -11,37 +11,79 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-// Hook represents a post-create hook to run after workspace creation.
+// Hook represents a command run at a stage of the workspace lifecycle.
+// A hook with a repo runs in that repo's directory; a hook without one is a
+// workspace-level hook that runs once in the workspace root.
 type Hook struct {
-	Repo string `mapstructure:"repo" yaml:"repo"`
-	Run  string `mapstructure:"run"  yaml:"run"`
+	Repo      string `mapstructure:"repo"       yaml:"repo,omitempty"       json:"repo"`
+	Run       string `mapstructure:"run"        yaml:"run"                  json:"run"`
+	Timeout   string `mapstructure:"timeout"    yaml:"timeout,omitempty"    json:"timeout,omitempty"`
+	OnFailure string `mapstructure:"on_failure" yaml:"on_failure,omitempty" json:"on_failure,omitempty"`
 }
 
 // Hooks holds the different hook stages.
 type Hooks struct {
-	PostCreate []Hook `mapstructure:"post_create" yaml:"post_create"`
+	PreCreate  []Hook `mapstructure:"pre_create"  yaml:"pre_create,omitempty"  json:"pre_create"`
+	PostCreate []Hook `mapstructure:"post_create" yaml:"post_create"           json:"post_create"`
+	PreRemove  []Hook `mapstructure:"pre_remove"  yaml:"pre_remove,omitempty"  json:"pre_remove"`
+	PostRemove []Hook `mapstructure:"post_remove" yaml:"post_remove,omitempty" json:"post_remove"`
+	PostApply  []Hook `mapstructure:"post_apply"  yaml:"post_apply,omitempty"  json:"post_apply"`
 }
 
 // Repo represents a single git repository within a profile.
 type Repo struct {
-	Name        string `mapstructure:"name"         yaml:"name"`
-	Path        string `mapstructure:"path"         yaml:"path"`
-	DefaultBase string `mapstructure:"default_base" yaml:"default_base"`
+	Name           string   `mapstructure:"name"            yaml:"name"                      json:"name"`
+	Path           string   `mapstructure:"path"            yaml:"path"                      json:"path"`
+	DefaultBase    string   `mapstructure:"default_base"    yaml:"default_base"              json:"default_base"`
+	BranchTemplate string   `mapstructure:"branch_template" yaml:"branch_template,omitempty" json:"branch_template,omitempty"`
+	Tags           []string `mapstructure:"tags"            yaml:"tags,omitempty"            json:"tags,omitempty"`
 }
 
 // Profile represents a named collection of repositories and their hooks.
 type Profile struct {
-	Repos []Repo `mapstructure:"repos" yaml:"repos"`
-	Hooks Hooks  `mapstructure:"hooks" yaml:"hooks"`
+	Repos          []Repo `mapstructure:"repos"           yaml:"repos"                     json:"repos"`
+	Hooks          Hooks  `mapstructure:"hooks"           yaml:"hooks"                     json:"hooks"`
+	BranchTemplate string `mapstructure:"branch_template" yaml:"branch_template,omitempty" json:"branch_template,omitempty"`
 }
 
 // Config is the top-level configuration structure.
+//
+// It is loaded in layers: the personal config file, then the files it includes,
+// then the nearest project file (ProjectConfigFile) if the personal config trusts it.
+// Earlier layers win: a setting or profile is taken from the first layer that defines
+// it. A project file only adds profiles.
 type Config struct {
-	BaseDir        string             `mapstructure:"base_dir"        yaml:"base_dir"`
-	DefaultProfile string             `mapstructure:"default_profile" yaml:"default_profile"`
+	BaseDir        string             `mapstructure:"base_dir"        yaml:"base_dir,omitempty"`
+	DefaultProfile string             `mapstructure:"default_profile" yaml:"default_profile,omitempty"`
 	Profiles       map[string]Profile `mapstructure:"profiles"        yaml:"profiles"`
+	Parallel       int                `mapstructure:"parallel"        yaml:"parallel,omitempty"`
+	// Include lists shared config files layered under this one, relative to this file.
+	Include []string `mapstructure:"include" yaml:"include,omitempty"`
+	// TrustedProjects lists the directories whose project file may be loaded, relative
+	// to this file. Only the personal config file can set it.
+	TrustedProjects []string `mapstructure:"trusted_projects" yaml:"trusted_projects,omitempty"`
+
+	// sources maps each profile defined by a shared layer to the file it comes from.
+	sources map[string]string
+	// keys records the settings the personal config file sets, which SaveConfig writes
+	// back. It is nil for a Config not read by LoadConfig, and then every setting is written.
+	keys map[string]bool
+	// written maps the base_dir and repo paths of the personal config file, as
+	// resolved, to the paths as written, so SaveConfig keeps relative paths relative.
+	written map[string]string
 }
 
+// ConfigEnv is the environment variable that overrides the personal config file path.
+const ConfigEnv = "MGV_CONFIG"
+
+// ProjectConfigFile is a shared profile file a team can commit in a repo. The nearest
+// one found from the current directory upward is layered under the personal config
+// when its directory is listed in trusted_projects.
+var ProjectConfigFile = filepath.Join(".mgv", "profiles.yaml")
+
+// defaultBaseDir is used when no layer sets base_dir.
+const defaultBaseDir = "~/mgv-workspaces"
+
 // ExpandPath expands ~ to the user's home directory.
 func ExpandPath(path string) string {
 	if strings.HasPrefix(path, "~/") {
@@ -69,36 +111,94 @@ func CollapsePath(path string) string {
 	return path
 }
 
-// SaveConfig writes the Config struct to ~/.config/mgv/config.yaml.
-func SaveConfig(cfg *Config) error {
+// ConfigDir returns the mgv config directory: $XDG_CONFIG_HOME/mgv, or ~/.config/mgv.
+func ConfigDir() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, "mgv"), nil
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf("cannot determine home directory: %w", err)
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
 	}
+	return filepath.Join(home, ".config", "mgv"), nil
+}
 
-	configDir := filepath.Join(home, ".config", "mgv")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+// ConfigPath returns the personal config file: $MGV_CONFIG, or config.yaml in ConfigDir.
+func ConfigPath() (string, error) {
+	if path := os.Getenv(ConfigEnv); path != "" {
+		return filepath.Abs(ExpandPath(path))
+	}
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "config.yaml"), nil
+}
+
+// ProfileSource returns the shared config file a profile comes from, or "" if it is
+// defined in the personal config.
+func (c *Config) ProfileSource(name string) string {
+	return c.sources[name]
+}
+
+// SetDefaultProfile sets the default profile in the personal config file.
+func (c *Config) SetDefaultProfile(name string) {
+	c.DefaultProfile = name
+	if c.keys != nil {
+		c.keys["default_profile"] = true
+	}
+}
+
+// SaveConfig writes the personal layer of the Config to ConfigPath. Profiles and
+// settings that come from shared layers are left out.
+func SaveConfig(cfg *Config) error {
+	configPath, err := ConfigPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	// Collapse paths to ~/ form for portable storage
+	// Write paths back as they were written, and new ones in ~/ form for portable storage
 	saveCfg := Config{
-		BaseDir:        CollapsePath(cfg.BaseDir),
-		DefaultProfile: cfg.DefaultProfile,
-		Profiles:       make(map[string]Profile, len(cfg.Profiles)),
+		BaseDir:         cfg.savePath(cfg.BaseDir),
+		DefaultProfile:  cfg.DefaultProfile,
+		Profiles:        make(map[string]Profile, len(cfg.Profiles)),
+		Parallel:        cfg.Parallel,
+		Include:         cfg.Include,
+		TrustedProjects: cfg.TrustedProjects,
+	}
+	if cfg.keys != nil {
+		if !cfg.keys["base_dir"] {
+			saveCfg.BaseDir = ""
+		}
+		if !cfg.keys["default_profile"] {
+			saveCfg.DefaultProfile = ""
+		}
+		if !cfg.keys["parallel"] {
+			saveCfg.Parallel = 0
+		}
 	}
 	for profileName, profile := range cfg.Profiles {
+		if cfg.ProfileSource(profileName) != "" {
+			continue
+		}
 		repos := make([]Repo, len(profile.Repos))
 		for i, repo := range profile.Repos {
 			repos[i] = Repo{
-				Name:        repo.Name,
-				Path:        CollapsePath(repo.Path),
-				DefaultBase: repo.DefaultBase,
+				Name:           repo.Name,
+				Path:           cfg.savePath(repo.Path),
+				DefaultBase:    repo.DefaultBase,
+				BranchTemplate: repo.BranchTemplate,
+				Tags:           repo.Tags,
 			}
 		}
 		saveCfg.Profiles[profileName] = Profile{
-			Repos: repos,
-			Hooks: profile.Hooks,
+			Repos:          repos,
+			Hooks:          profile.Hooks,
+			BranchTemplate: profile.BranchTemplate,
 		}
 	}
 
@@ -107,7 +207,6 @@ func SaveConfig(cfg *Config) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	configPath := filepath.Join(configDir, "config.yaml")
 	if err := os.WriteFile(configPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
@@ -115,6 +214,15 @@ func SaveConfig(cfg *Config) error {
 	return nil
 }
 
+// savePath returns a path as the personal config file wrote it, or in ~/ form if
+// the file did not have it.
+func (c *Config) savePath(path string) string {
+	if written, ok := c.written[path]; ok {
+		return written
+	}
+	return CollapsePath(path)
+}
+
 // DetectDefaultBranch detects the default branch of a remote repository.
 // Falls back to "main" on error.
 func DetectDefaultBranch(repoPath string) string {
@@ -133,48 +241,230 @@ func DetectDefaultBranch(repoPath string) string {
 	return "main"
 }
 
-// LoadConfig reads the configuration from ~/.config/mgv/config.yaml.
+// LoadConfig reads the personal config file (ConfigPath) and layers the files it
+// includes and the nearest trusted project file (ProjectConfigFile) under it. Relative
+// paths in each file are resolved against that file's directory.
 func LoadConfig() (*Config, error) {
-	home, err := os.UserHomeDir()
+	configPath, err := ConfigPath()
 	if err != nil {
-		return nil, fmt.Errorf("cannot determine home directory: %w", err)
+		return nil, err
+	}
+	if _, err := os.Stat(configPath); err != nil {
+		return nil, fmt.Errorf("config file not found at %s", configPath)
 	}
 
-	configDir := filepath.Join(home, ".config", "mgv")
+	seen := map[string]bool{}
+	layers, err := readConfigLayers([]string{configPath}, seen)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		Profiles:        make(map[string]Profile),
+		Include:         layers[0].cfg.Include,
+		TrustedProjects: layers[0].cfg.TrustedProjects,
+		sources:         make(map[string]string),
+		keys:            layers[0].cfg.keys,
+		written:         layers[0].cfg.written,
+	}
+	for _, layer := range layers {
+		if cfg.BaseDir == "" {
+			cfg.BaseDir = layer.cfg.BaseDir
+		}
+		if cfg.DefaultProfile == "" {
+			cfg.DefaultProfile = layer.cfg.DefaultProfile
+		}
+		if cfg.Parallel == 0 {
+			cfg.Parallel = layer.cfg.Parallel
+		}
+		cfg.addProfiles(layer, layer.path != configPath)
+	}
 
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(configDir)
+	// A project file and the files it includes only add profiles
+	if project := findProjectConfig(); project != "" && trustedProject(configPath, cfg.TrustedProjects, project) {
+		projectLayers, err := readConfigLayers([]string{project}, seen)
+		if err != nil {
+			return nil, err
+		}
+		for _, layer := range projectLayers {
+			cfg.addProfiles(layer, true)
+		}
+	}
 
-	// Set defaults
-	viper.SetDefault("base_dir", "~/mgv-workspaces")
-	viper.SetDefault("default_profile", "")
-	viper.SetDefault("profiles", map[string]Profile{})
+	if cfg.BaseDir == "" {
+		cfg.BaseDir = ExpandPath(defaultBaseDir)
+	}
+	return cfg, nil
+}
 
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			return nil, fmt.Errorf("config file not found at %s/config.yaml: %w", configDir, err)
+// addProfiles adds the profiles of a layer that no earlier layer defines.
+// Profiles of a shared layer are recorded with their source file.
+func (c *Config) addProfiles(layer configLayer, shared bool) {
+	for name, profile := range layer.cfg.Profiles {
+		if _, ok := c.Profiles[name]; ok {
+			continue
+		}
+		c.Profiles[name] = profile
+		if shared {
+			c.sources[name] = layer.path
 		}
-		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+}
+
+// configLayer is a config file read by LoadConfig.
+type configLayer struct {
+	path string
+	cfg  *Config
+}
+
+// readConfigLayers reads each file followed by the files it includes, depth first.
+// Files already read are skipped, so include cycles are harmless.
+func readConfigLayers(paths []string, seen map[string]bool) ([]configLayer, error) {
+	var layers []configLayer
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		cfg, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, configLayer{path: path, cfg: cfg})
+
+		dir := filepath.Dir(path)
+		includes := make([]string, len(cfg.Include))
+		for i, include := range cfg.Include {
+			includes[i] = resolvePath(dir, include)
+		}
+		included, err := readConfigLayers(includes, seen)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, included...)
+	}
+	return layers, nil
+}
+
+// readConfigFile reads a single config file, resolving base_dir and repo paths against
+// the file's directory and recording which settings it sets and how its paths were
+// written. Include entries are kept as written.
+func readConfigFile(path string) (*Config, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config %s: %w", path, err)
 	}
 
 	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config: %w", err)
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	cfg.keys = make(map[string]bool)
+	for _, key := range []string{"base_dir", "default_profile", "parallel"} {
+		cfg.keys[key] = v.IsSet(key)
 	}
 
-	// Expand paths
-	cfg.BaseDir = ExpandPath(cfg.BaseDir)
+	dir := filepath.Dir(path)
+	cfg.written = make(map[string]string)
+	resolve := func(p string) string {
+		resolved := resolvePath(dir, p)
+		cfg.written[resolved] = p
+		return resolved
+	}
+	if cfg.BaseDir != "" {
+		cfg.BaseDir = resolve(cfg.BaseDir)
+	}
 	for profileName, profile := range cfg.Profiles {
 		for i := range profile.Repos {
-			profile.Repos[i].Path = ExpandPath(profile.Repos[i].Path)
+			profile.Repos[i].Path = resolve(profile.Repos[i].Path)
 		}
 		cfg.Profiles[profileName] = profile
 	}
-
 	return &cfg, nil
 }
 
+// resolvePath expands ~ and makes a relative path absolute against dir.
+func resolvePath(dir, path string) string {
+	path = ExpandPath(path)
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// mainWorktreeFile maps a file inside a linked worktree (such as a workspace repo) to
+// the same file in the repo's main worktree, so that its relative paths resolve
+// against the original repo. Other files are returned unchanged.
+func mainWorktreeFile(dir, path string) string {
+	cmd := exec.Command("git", "-C", dir, "rev-parse", "--path-format=absolute", "--show-toplevel", "--git-common-dir")
+	output, err := cmd.Output()
+	if err != nil {
+		return path
+	}
+	lines := parseLines(string(output))
+	if len(lines) != 2 || filepath.Base(lines[1]) != ".git" {
+		return path
+	}
+	top, mainDir := lines[0], filepath.Dir(lines[1])
+	if top == mainDir {
+		return path
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(top, resolved)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	mainPath := filepath.Join(mainDir, rel)
+	if _, err := os.Stat(mainPath); err != nil {
+		return path
+	}
+	return mainPath
+}
+
+// findProjectConfig returns the nearest ProjectConfigFile from the current directory
+// upward, or "" if there is none.
+func findProjectConfig() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ProjectConfigFile)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return mainWorktreeFile(dir, path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// trustedProject reports whether project is the ProjectConfigFile of one of the trusted
+// directories, which are relative to the personal config file's directory.
+func trustedProject(configPath string, trusted []string, project string) bool {
+	resolved, err := filepath.EvalSymlinks(project)
+	if err != nil {
+		return false
+	}
+	dir := filepath.Dir(configPath)
+	for _, t := range trusted {
+		path, err := filepath.EvalSymlinks(filepath.Join(resolvePath(dir, t), ProjectConfigFile))
+		if err == nil && path == resolved {
+			return true
+		}
+	}
+	return false
+}
+
 // GetProfile returns the named profile from the config.
 // If name is empty, returns the default profile.
 func (c *Config) GetProfile(name string) (*Profile, string, error) {
@@ -214,12 +504,16 @@ func (c *Config) AddProfile(name string, profile Profile) error {
 }
 
 // AddRepoToProfile adds a repository to an existing profile.
-// Returns an error if the profile does not exist or if a repo with the same name already exists.
+// Returns an error if the profile does not exist, comes from a shared config file,
+// or if a repo with the same name already exists.
 func (c *Config) AddRepoToProfile(profileName string, repo Repo) error {
 	profile, ok := c.Profiles[profileName]
 	if !ok {
 		return fmt.Errorf("profile %q not found", profileName)
 	}
+	if err := c.checkPersonal(profileName); err != nil {
+		return err
+	}
 	for _, r := range profile.Repos {
 		if r.Name == repo.Name {
 			return fmt.Errorf("repository %q already exists in profile %q", repo.Name, profileName)
@@ -231,12 +525,16 @@ func (c *Config) AddRepoToProfile(profileName string, repo Repo) error {
 }
 
 // RemoveRepoFromProfile removes a repository from an existing profile by name.
-// Returns an error if the profile or repository is not found.
+// Returns an error if the profile or repository is not found, or if the profile
+// comes from a shared config file.
 func (c *Config) RemoveRepoFromProfile(profileName, repoName string) error {
 	profile, ok := c.Profiles[profileName]
 	if !ok {
 		return fmt.Errorf("profile %q not found", profileName)
 	}
+	if err := c.checkPersonal(profileName); err != nil {
+		return err
+	}
 	found := false
 	repos := make([]Repo, 0, len(profile.Repos))
 	for _, r := range profile.Repos {
@@ -254,6 +552,24 @@ func (c *Config) RemoveRepoFromProfile(profileName, repoName string) error {
 	return nil
 }
 
+// checkPersonal returns an error if a profile comes from a shared config file,
+// which SaveConfig never writes.
+func (c *Config) checkPersonal(profileName string) error {
+	if source := c.ProfileSource(profileName); source != "" {
+		return fmt.Errorf("profile %q is defined in %s; edit that file instead", profileName, source)
+	}
+	return nil
+}
+
+// GetParallelism returns the maximum number of repos to process concurrently,
+// falling back to DefaultParallelism if not set.
+func (c *Config) GetParallelism() int {
+	if c.Parallel > 0 {
+		return c.Parallel
+	}
+	return DefaultParallelism
+}
+
 // GetRepoDefaultBase returns the default base branch for a repo,
 // falling back to "main" if not set.
 func (r *Repo) GetDefaultBase() string {
@@ -3,8 +3,11 @@ package mangrove
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestExpandPath(t *testing.T) {
@@ -378,3 +381,338 @@ func TestGetDefaultBase(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(ConfigEnv, "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	if got, _ := ConfigPath(); got != filepath.Join(home, ".config", "mgv", "config.yaml") {
+		t.Errorf("ConfigPath() = %q, want the default under ~/.config", got)
+	}
+
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	if got, _ := ConfigPath(); got != filepath.Join(xdg, "mgv", "config.yaml") {
+		t.Errorf("ConfigPath() = %q, want it under $XDG_CONFIG_HOME", got)
+	}
+
+	t.Setenv(ConfigEnv, "~/mgv.yaml")
+	if got, _ := ConfigPath(); got != filepath.Join(home, "mgv.yaml") {
+		t.Errorf("ConfigPath() = %q, want $%s expanded", got, ConfigEnv)
+	}
+}
+
+// writeConfigFile writes a config file, creating its directory.
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadConfigLayers(t *testing.T) {
+	root := t.TempDir()
+	personal := filepath.Join(root, "home", "mgv.yaml")
+	t.Setenv(ConfigEnv, personal)
+
+	writeConfigFile(t, personal, `
+default_profile: mine
+include:
+  - team/shared.yaml
+trusted_projects:
+  - ../project
+profiles:
+  mine:
+    repos:
+      - name: api
+        path: src/api
+  both:
+    repos:
+      - name: personal
+        path: /personal
+`)
+	writeConfigFile(t, filepath.Join(root, "home", "team", "shared.yaml"), `
+base_dir: ../workspaces
+parallel: 3
+profiles:
+  team:
+    repos:
+      - name: web
+        path: ../web
+  both:
+    repos:
+      - name: shared
+        path: /shared
+`)
+	project := filepath.Join(root, "project")
+	writeConfigFile(t, filepath.Join(project, ProjectConfigFile), `
+base_dir: /elsewhere
+parallel: 9
+profiles:
+  proj:
+    repos:
+      - name: svc
+        path: ../svc
+`)
+	t.Chdir(project)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() unexpected error: %v", err)
+	}
+
+	if cfg.DefaultProfile != "mine" {
+		t.Errorf("DefaultProfile = %q, want the personal one", cfg.DefaultProfile)
+	}
+	if want := filepath.Join(root, "home", "workspaces"); cfg.BaseDir != want {
+		t.Errorf("BaseDir = %q, want %q", cfg.BaseDir, want)
+	}
+	if cfg.Parallel != 3 {
+		t.Errorf("Parallel = %d, want 3 from the shared file", cfg.Parallel)
+	}
+
+	paths := map[string]string{
+		"mine": filepath.Join(root, "home", "src", "api"),
+		"team": filepath.Join(root, "home", "web"),
+		"proj": filepath.Join(project, "svc"),
+		"both": "/personal",
+	}
+	for name, want := range paths {
+		profile, _, err := cfg.GetProfile(name)
+		if err != nil {
+			t.Errorf("GetProfile(%q) unexpected error: %v", name, err)
+			continue
+		}
+		if got := profile.Repos[0].Path; got != want {
+			t.Errorf("%s repo path = %q, want %q", name, got, want)
+		}
+	}
+
+	if got := cfg.ProfileSource("mine"); got != "" {
+		t.Errorf("ProfileSource(mine) = %q, want personal", got)
+	}
+	if got := cfg.ProfileSource("team"); got != filepath.Join(root, "home", "team", "shared.yaml") {
+		t.Errorf("ProfileSource(team) = %q, want the shared file", got)
+	}
+	if err := cfg.AddRepoToProfile("team", Repo{Name: "x", Path: "/x"}); err == nil {
+		t.Error("AddRepoToProfile() on a shared profile should fail")
+	}
+
+	// SaveConfig writes only the personal layer
+	if err := cfg.AddRepoToProfile("mine", Repo{Name: "x", Path: "/x"}); err != nil {
+		t.Fatalf("AddRepoToProfile() unexpected error: %v", err)
+	}
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatalf("SaveConfig() unexpected error: %v", err)
+	}
+	saved, err := readConfigFile(personal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range saved.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if !slices.Equal(names, []string{"both", "mine"}) {
+		t.Errorf("saved profiles = %v, want [both mine]", names)
+	}
+	if !slices.Equal(saved.TrustedProjects, []string{"../project"}) {
+		t.Errorf("saved trusted_projects = %q, want it kept as written", saved.TrustedProjects)
+	}
+	if saved.BaseDir != "" || saved.Parallel != 0 {
+		t.Errorf("saved base_dir = %q, parallel = %d, want the shared settings left out", saved.BaseDir, saved.Parallel)
+	}
+	if !slices.Equal(saved.Include, []string{"team/shared.yaml"}) {
+		t.Errorf("saved include = %q, want it kept as written", saved.Include)
+	}
+	if len(saved.Profiles["mine"].Repos) != 2 {
+		t.Errorf("saved mine repos = %+v, want the added repo", saved.Profiles["mine"].Repos)
+	}
+}
+
+func TestSaveConfigInProject(t *testing.T) {
+	root := t.TempDir()
+	personal := filepath.Join(root, "mgv.yaml")
+	t.Setenv(ConfigEnv, personal)
+
+	// The project file repeats the personal settings; saving must keep them
+	writeConfigFile(t, personal, `
+default_profile: mine
+parallel: 2
+trusted_projects:
+  - project
+profiles:
+  mine:
+    repos:
+      - name: api
+        path: /api
+`)
+	project := filepath.Join(root, "project")
+	writeConfigFile(t, filepath.Join(project, ProjectConfigFile), `
+default_profile: mine
+parallel: 2
+base_dir: /elsewhere
+profiles:
+  proj:
+    repos:
+      - name: svc
+        path: /svc
+`)
+	t.Chdir(project)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() unexpected error: %v", err)
+	}
+	if _, ok := cfg.Profiles["proj"]; !ok {
+		t.Fatal("the trusted project profile should be loaded")
+	}
+	if cfg.BaseDir == "/elsewhere" {
+		t.Error("base_dir should never come from a project file")
+	}
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatalf("SaveConfig() unexpected error: %v", err)
+	}
+	saved, err := readConfigFile(personal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.DefaultProfile != "mine" || saved.Parallel != 2 {
+		t.Errorf("saved default_profile = %q, parallel = %d, want the personal settings kept", saved.DefaultProfile, saved.Parallel)
+	}
+	if saved.BaseDir != "" {
+		t.Errorf("saved base_dir = %q, want it left unset", saved.BaseDir)
+	}
+	if _, ok := saved.Profiles["proj"]; ok {
+		t.Error("the project profile should not be saved")
+	}
+}
+
+func TestSaveConfigKeepsRelativePaths(t *testing.T) {
+	root := t.TempDir()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	personal := filepath.Join(root, "mgv.yaml")
+	t.Setenv(ConfigEnv, personal)
+
+	writeConfigFile(t, personal, `
+base_dir: workspaces
+profiles:
+  mine:
+    repos:
+      - name: api
+        path: ../repos/api
+      - name: web
+        path: ~/src/web
+`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() unexpected error: %v", err)
+	}
+	if want := filepath.Join(root, "workspaces"); cfg.BaseDir != want {
+		t.Errorf("BaseDir = %q, want %q", cfg.BaseDir, want)
+	}
+	profile := cfg.Profiles["mine"]
+	profile.Repos = append(profile.Repos, Repo{Name: "docs", Path: filepath.Join(home, "src", "docs")})
+	cfg.Profiles["mine"] = profile
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatalf("SaveConfig() unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(personal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved Config
+	if err := yaml.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.BaseDir != "workspaces" {
+		t.Errorf("saved base_dir = %q, want it kept as written", saved.BaseDir)
+	}
+	var paths []string
+	for _, repo := range saved.Profiles["mine"].Repos {
+		paths = append(paths, repo.Path)
+	}
+	if want := []string{"../repos/api", "~/src/web", "~/src/docs"}; !slices.Equal(paths, want) {
+		t.Errorf("saved repo paths = %q, want %q", paths, want)
+	}
+}
+
+func TestLoadConfigUntrustedProject(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(ConfigEnv, filepath.Join(root, "mgv.yaml"))
+	writeConfigFile(t, filepath.Join(root, "mgv.yaml"), "profiles: {}\n")
+
+	project := filepath.Join(root, "project")
+	writeConfigFile(t, filepath.Join(project, ProjectConfigFile), `
+default_profile: proj
+base_dir: /elsewhere
+profiles:
+  proj:
+    repos:
+      - name: svc
+        path: /svc
+    hooks:
+      post_create:
+        - run: echo untrusted
+`)
+	t.Chdir(project)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() unexpected error: %v", err)
+	}
+	if _, ok := cfg.Profiles["proj"]; ok {
+		t.Error("a project file outside trusted_projects should not be loaded")
+	}
+	if cfg.DefaultProfile != "" || cfg.BaseDir == "/elsewhere" {
+		t.Errorf("DefaultProfile = %q, BaseDir = %q, want nothing from the project file", cfg.DefaultProfile, cfg.BaseDir)
+	}
+}
+
+func TestLoadConfigMissing(t *testing.T) {
+	t.Setenv(ConfigEnv, filepath.Join(t.TempDir(), "missing.yaml"))
+	t.Chdir(t.TempDir())
+
+	if _, err := LoadConfig(); err == nil {
+		t.Error("LoadConfig() without any config file should fail")
+	}
+}
+
+func TestLoadConfigProjectInWorktree(t *testing.T) {
+	// A project file committed in a repo resolves against the original repo, even
+	// when mgv runs inside one of its worktrees
+	repo := newTestRepo(t)
+	personal := filepath.Join(t.TempDir(), "mgv.yaml")
+	t.Setenv(ConfigEnv, personal)
+	writeConfigFile(t, personal, "trusted_projects:\n  - "+repo+"\n")
+
+	if err := os.Mkdir(filepath.Join(repo, ".mgv"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeAndCommit(t, repo, ProjectConfigFile, "profiles:\n  team:\n    repos:\n      - name: api\n        path: ..\n", "add profiles")
+	wt := filepath.Join(t.TempDir(), "wt")
+	runGit(t, repo, "worktree", "add", "-q", "-b", "feat", wt)
+	t.Chdir(wt)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() unexpected error: %v", err)
+	}
+	profile, _, err := cfg.GetProfile("team")
+	if err != nil {
+		t.Fatalf("GetProfile() unexpected error: %v", err)
+	}
+	want, _ := filepath.EvalSymlinks(repo)
+	if got := profile.Repos[0].Path; got != want {
+		t.Errorf("repo path = %q, want the original repo %q", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package mangrove
+
+import (
+	"fmt"
+	"io"
+)
+
+// Diff modes.
+const (
+	// DiffWorktree compares the working tree against the index, like git diff.
+	DiffWorktree = "worktree"
+	// DiffStaged compares the index against HEAD, like git diff --staged.
+	DiffStaged = "staged"
+	// DiffBase compares the working tree against the commit the workspace was created from.
+	DiffBase = "base"
+)
+
+// DiffTarget is a worktree to show the diff or log of, labelled by repo name.
+type DiffTarget struct {
+	Name   string
+	Dir    string
+	Branch string
+	// Base is the branch the workspace was created from.
+	Base string
+	// BaseCommit is the commit the workspace was created from, if recorded.
+	BaseCommit string
+}
+
+// WriteDiff writes the diff of every target to w and returns how many repos had
+// changes. With patch set, the diffs form a single unified patch whose paths are
+// prefixed with the repo name, so it applies from the workspace root; otherwise
+// each non-empty diff is preceded by a repo header.
+func WriteDiff(w io.Writer, targets []DiffTarget, mode string, patch, color bool) (int, error) {
+	changed := 0
+	for _, t := range targets {
+		var rev string
+		staged := false
+		switch mode {
+		case DiffWorktree:
+		case DiffStaged:
+			staged = true
+		case DiffBase:
+			base, err := creationBase(t)
+			if err != nil {
+				return changed, fmt.Errorf("%s: %w", t.Name, err)
+			}
+			rev = base
+		default:
+			return changed, fmt.Errorf("unknown diff mode %q", mode)
+		}
+
+		out, err := Diff(t.Dir, rev, staged, t.Name+"/", color && !patch)
+		if err != nil {
+			return changed, fmt.Errorf("%s: %w", t.Name, err)
+		}
+		if len(out) == 0 {
+			continue
+		}
+		changed++
+
+		if !patch {
+			writeRepoHeader(w, t, changed == 1)
+		}
+		if _, err := w.Write(out); err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+// WriteLog writes the commits on each target's branch since its base to w and
+// returns how many repos had commits. With patch set, every commit is written
+// in full with its diff, paths prefixed with the repo name, and no repo headers.
+func WriteLog(w io.Writer, targets []DiffTarget, patch, color bool) (int, error) {
+	changed := 0
+	for _, t := range targets {
+		out, err := Log(t.Dir, t.Base+"..HEAD", patch, t.Name+"/", color && !patch)
+		if err != nil {
+			return changed, fmt.Errorf("%s: %w", t.Name, err)
+		}
+		if len(out) == 0 {
+			continue
+		}
+		changed++
+
+		if !patch {
+			writeRepoHeader(w, t, changed == 1)
+		}
+		if _, err := w.Write(out); err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+// creationBase returns the commit a worktree was created from, falling back to
+// the merge base with its base branch when it is not recorded or no longer exists.
+func creationBase(t DiffTarget) (string, error) {
+	if t.BaseCommit != "" {
+		if _, err := RevParse(t.Dir, t.BaseCommit); err == nil {
+			return t.BaseCommit, nil
+		}
+	}
+	return MergeBase(t.Dir, t.Base, "HEAD")
+}
+
+// writeRepoHeader writes the header that separates repos in grouped output.
+func writeRepoHeader(w io.Writer, t DiffTarget, first bool) {
+	if !first {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%s %s %s\n",
+		RepoNameStyle.Render("── "+t.Name),
+		BranchNameStyle.Render(t.Branch),
+		DimStyle.Render("(base: "+t.Base+")"),
+	)
+}
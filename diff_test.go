@@ -0,0 +1,156 @@
+package mangrove
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteDiff(t *testing.T) {
+	repos := []Repo{
+		{Name: "api", Path: newTestRepo(t)},
+		{Name: "web", Path: newTestRepo(t)},
+	}
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos}
+	if err := CreateWorkspace(cfg, profile, "proj", "feat", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	wsPath := GetWorkspacePath(cfg, "proj", "feat")
+	meta, _ := LoadWorkspaceMetadata(wsPath)
+
+	var targets []DiffTarget
+	for _, repo := range repos {
+		targets = append(targets, DiffTarget{
+			Name:       repo.Name,
+			Dir:        filepath.Join(wsPath, repo.Name),
+			Branch:     "feat",
+			Base:       "main",
+			BaseCommit: meta.FindRepo(repo.Name).BaseCommit,
+		})
+	}
+	apiDir, webDir := targets[0].Dir, targets[1].Dir
+
+	// api: a commit and an unstaged change; web: a staged change
+	writeAndCommit(t, apiDir, "committed.txt", "c\n", "committed work")
+	if err := os.WriteFile(filepath.Join(apiDir, "README.md"), []byte("unstaged\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(webDir, "README.md"), []byte("staged\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, webDir, "add", "README.md")
+
+	tests := []struct {
+		mode    string
+		changed int
+		want    []string
+		notWant []string
+	}{
+		{DiffWorktree, 1, []string{"a/api/README.md", "+unstaged"}, []string{"web/", "committed.txt"}},
+		{DiffStaged, 1, []string{"a/web/README.md", "+staged"}, []string{"api/"}},
+		{DiffBase, 2, []string{"b/api/committed.txt", "+unstaged", "+staged"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			var buf bytes.Buffer
+			changed, err := WriteDiff(&buf, targets, tt.mode, true, false)
+			if err != nil {
+				t.Fatalf("WriteDiff() unexpected error: %v", err)
+			}
+			if changed != tt.changed {
+				t.Errorf("WriteDiff() changed = %d, want %d", changed, tt.changed)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("diff missing %q:\n%s", s, buf.String())
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(buf.String(), s) {
+					t.Errorf("diff should not contain %q:\n%s", s, buf.String())
+				}
+			}
+		})
+	}
+
+	// The base patch applies from the root of a copy of the base tree
+	var patch bytes.Buffer
+	if _, err := WriteDiff(&patch, targets, DiffBase, true, false); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	for _, repo := range repos {
+		if err := os.MkdirAll(filepath.Join(root, repo.Name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, repo.Name, "README.md"), []byte("hello\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, root, "init", "-q")
+	patchFile := filepath.Join(t.TempDir(), "ws.patch")
+	if err := os.WriteFile(patchFile, patch.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, root, "apply", patchFile)
+	if data, _ := os.ReadFile(filepath.Join(root, "web", "README.md")); string(data) != "staged\n" {
+		t.Errorf("web/README.md after apply = %q, want staged", data)
+	}
+
+	// Grouped output has a header per repo with changes
+	var grouped bytes.Buffer
+	if _, err := WriteDiff(&grouped, targets, DiffBase, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(grouped.String(), "── api") || !strings.Contains(grouped.String(), "── web") {
+		t.Errorf("grouped diff missing repo headers:\n%s", grouped.String())
+	}
+
+	if _, err := WriteDiff(&grouped, targets, "bogus", false, false); err == nil {
+		t.Error("WriteDiff() with an unknown mode should fail")
+	}
+}
+
+func TestWriteLog(t *testing.T) {
+	repos := []Repo{
+		{Name: "api", Path: newTestRepo(t)},
+		{Name: "web", Path: newTestRepo(t)},
+	}
+	cfg := &Config{BaseDir: t.TempDir()}
+	if err := CreateWorkspace(cfg, &Profile{Repos: repos}, "proj", "feat", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	wsPath := GetWorkspacePath(cfg, "proj", "feat")
+	targets := []DiffTarget{
+		{Name: "api", Dir: filepath.Join(wsPath, "api"), Branch: "feat", Base: "main"},
+		{Name: "web", Dir: filepath.Join(wsPath, "web"), Branch: "feat", Base: "main"},
+	}
+	writeAndCommit(t, targets[0].Dir, "feature.txt", "v1\n", "add feature")
+
+	var buf bytes.Buffer
+	changed, err := WriteLog(&buf, targets, false, false)
+	if err != nil {
+		t.Fatalf("WriteLog() unexpected error: %v", err)
+	}
+	if changed != 1 {
+		t.Errorf("WriteLog() changed = %d, want 1", changed)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "── api") || !strings.Contains(out, "add feature") {
+		t.Errorf("log missing api commit:\n%s", out)
+	}
+	if strings.Contains(out, "initial commit") || strings.Contains(out, "── web") {
+		t.Errorf("log should only contain commits since the base:\n%s", out)
+	}
+
+	buf.Reset()
+	if _, err := WriteLog(&buf, targets, true, false); err != nil {
+		t.Fatalf("WriteLog() unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "b/api/feature.txt") || strings.Contains(buf.String(), "──") {
+		t.Errorf("patch log should have prefixed paths and no headers:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,391 @@
+package mangrove
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Doctor issue kinds.
+const (
+	// IssueOrphan is a worktree registered under base_dir whose directory no longer exists.
+	IssueOrphan = "orphan"
+	// IssueMissing is a workspace repo whose worktree directory is gone or not registered in the repo.
+	IssueMissing = "missing"
+	// IssueLocked is a locked worktree under base_dir. Locked worktrees are never fixed automatically.
+	IssueLocked = "locked"
+	// IssuePrunable is a worktree that git reports as prunable.
+	IssuePrunable = "prunable"
+	// IssueBranch is a branch left behind by an orphaned or removed workspace worktree.
+	IssueBranch = "branch"
+	// IssueRepo is a configured repo whose worktrees cannot be listed.
+	IssueRepo = "repo"
+)
+
+// DoctorIssue is a problem found by Diagnose.
+type DoctorIssue struct {
+	Kind      string `json:"kind"                yaml:"kind"`
+	Profile   string `json:"profile,omitempty"   yaml:"profile,omitempty"`
+	Workspace string `json:"workspace,omitempty" yaml:"workspace,omitempty"`
+	Repo      string `json:"repo"                yaml:"repo"`
+	RepoPath  string `json:"repo_path"           yaml:"repo_path"`
+	Path      string `json:"path,omitempty"      yaml:"path,omitempty"`
+	Branch    string `json:"branch,omitempty"    yaml:"branch,omitempty"`
+	Base      string `json:"base,omitempty"      yaml:"base,omitempty"`
+	Detail    string `json:"detail"              yaml:"detail"`
+	Fixed     bool   `json:"fixed"               yaml:"fixed"`
+}
+
+// Label returns a short description of what the issue is about.
+func (i *DoctorIssue) Label() string {
+	if i.Profile != "" && i.Workspace != "" {
+		return fmt.Sprintf("%s/%s/%s", i.Profile, i.Workspace, i.Repo)
+	}
+	return i.Repo
+}
+
+// Fixable reports whether FixIssues can repair the issue.
+// Branch issues are only fixed when deleting branches is allowed.
+func (i *DoctorIssue) Fixable(deleteBranches bool) bool {
+	switch i.Kind {
+	case IssueOrphan, IssuePrunable, IssueMissing:
+		return true
+	case IssueBranch:
+		return deleteBranches
+	default:
+		return false
+	}
+}
+
+// doctorRepo is a source repository whose worktrees are checked.
+type doctorRepo struct {
+	name    string
+	path    string
+	entries []WorktreeEntry
+}
+
+// Diagnose cross-checks the worktrees registered in every repo of the scanned
+// profiles against the workspaces in base_dir. If profileName is empty, all
+// profiles are scanned.
+func Diagnose(cfg *Config, profileName string) ([]DoctorIssue, error) {
+	profiles, err := scanProfiles(cfg, profileName)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var issues []DoctorIssue
+	var repos []*doctorRepo
+	byPath := make(map[string]*doctorRepo)
+	lookup := func(repo Repo) *doctorRepo {
+		key := canonicalPath(repo.Path)
+		if r, ok := byPath[key]; ok {
+			return r
+		}
+		r := &doctorRepo{name: repo.Name, path: repo.Path}
+		entries, err := WorktreeList(repo.Path)
+		if err != nil {
+			issues = append(issues, DoctorIssue{Kind: IssueRepo, Repo: repo.Name, RepoPath: repo.Path, Detail: err.Error()})
+		}
+		r.entries = entries
+		byPath[key] = r
+		repos = append(repos, r)
+		return r
+	}
+
+	for _, pName := range names {
+		profile := profiles[pName]
+		for _, repo := range profile.Repos {
+			lookup(repo)
+		}
+	}
+
+	// Every workspace repo should have a registered worktree
+	for _, pName := range names {
+		profile := profiles[pName]
+		entries, err := os.ReadDir(filepath.Join(cfg.BaseDir, pName))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read profile directory: %w", err)
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			wsName := entry.Name()
+			wsPath := GetWorkspacePath(cfg, pName, wsName)
+			meta, err := LoadWorkspaceMetadata(wsPath)
+			if err != nil {
+				PrintWarning("%s/%s: %v", pName, wsName, err)
+			}
+
+			for _, repo := range WorkspaceRepos(meta, &profile) {
+				r := lookup(repo)
+				dir := filepath.Join(wsPath, repo.Name)
+				if r.entries == nil || r.findWorktree(dir) != nil {
+					continue
+				}
+
+				detail := "worktree directory not found"
+				if _, err := os.Stat(dir); err == nil {
+					detail = "directory exists but is not registered as a worktree"
+				}
+				branch, err := WorkspaceBranch(meta, &profile, &repo, pName, wsName)
+				if err != nil {
+					detail += ": " + err.Error()
+				}
+				issues = append(issues, DoctorIssue{
+					Kind:      IssueMissing,
+					Profile:   pName,
+					Workspace: wsName,
+					Repo:      repo.Name,
+					RepoPath:  repo.Path,
+					Path:      dir,
+					Branch:    branch,
+					Base:      meta.RepoBase(&repo),
+					Detail:    detail,
+				})
+			}
+		}
+	}
+
+	// Registered worktrees should still exist
+	baseDir := canonicalPath(cfg.BaseDir)
+	for _, r := range repos {
+		for i, e := range r.entries {
+			// The first entry is the main worktree
+			if i == 0 || e.Bare {
+				continue
+			}
+
+			issue := DoctorIssue{
+				Repo:     r.name,
+				RepoPath: r.path,
+				Path:     e.Worktree,
+				Branch:   strings.TrimPrefix(e.Branch, "refs/heads/"),
+			}
+			var inBaseDir bool
+			issue.Profile, issue.Workspace, inBaseDir = worktreeWorkspace(baseDir, e.Worktree)
+
+			_, statErr := os.Stat(e.Worktree)
+			switch {
+			case e.Locked && (inBaseDir || e.Prunable):
+				issue.Kind = IssueLocked
+				issue.Detail = "worktree is locked"
+				if e.LockReason != "" {
+					issue.Detail += ": " + e.LockReason
+				}
+				issues = append(issues, issue)
+			case inBaseDir && os.IsNotExist(statErr):
+				issue.Kind = IssueOrphan
+				issue.Detail = "worktree directory not found"
+				issues = append(issues, issue)
+				if issue.Branch != "" {
+					branch := issue
+					branch.Kind = IssueBranch
+					branch.Detail = "branch has no workspace"
+					issues = append(issues, branch)
+				}
+			case e.Prunable:
+				issue.Kind = IssuePrunable
+				issue.Detail = e.PrunableReason
+				issues = append(issues, issue)
+			}
+		}
+	}
+
+	// Branches created for a workspace should not outlive it. Archived workspaces
+	// keep their branches on purpose.
+	archives, err := ListArchives("")
+	if err != nil {
+		return nil, err
+	}
+	archived := make(map[[2]string]bool)
+	for _, record := range archives {
+		for _, ar := range record.Repos {
+			archived[[2]string{canonicalPath(ar.Path), ar.Branch}] = true
+		}
+	}
+	for _, r := range repos {
+		if r.entries == nil {
+			continue
+		}
+		recorded, err := WorktreeBranches(r.path)
+		if err != nil {
+			issues = append(issues, DoctorIssue{Kind: IssueRepo, Repo: r.name, RepoPath: r.path, Detail: err.Error()})
+			continue
+		}
+		branches := make([]string, 0, len(recorded))
+		for branch := range recorded {
+			branches = append(branches, branch)
+		}
+		sort.Strings(branches)
+
+		for _, branch := range branches {
+			path := recorded[branch]
+			// Checked-out branches are covered above, and a workspace that is still
+			// there reports its worktree as missing instead
+			if r.checksOut(branch) || archived[[2]string{canonicalPath(r.path), branch}] {
+				continue
+			}
+			if _, err := os.Stat(filepath.Dir(path)); err == nil {
+				continue
+			}
+			if !RefExists(r.path, "refs/heads/"+branch) {
+				continue
+			}
+			issue := DoctorIssue{
+				Kind:     IssueBranch,
+				Repo:     r.name,
+				RepoPath: r.path,
+				Path:     path,
+				Branch:   branch,
+				Detail:   "branch has no workspace",
+			}
+			issue.Profile, issue.Workspace, _ = worktreeWorkspace(baseDir, path)
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, nil
+}
+
+// worktreeWorkspace returns the profile and workspace of a worktree path under
+// baseDir (<base_dir>/<profile>/<workspace>/<repo>), and whether the path is under
+// baseDir at all.
+func worktreeWorkspace(baseDir, path string) (profileName, wsName string, inBaseDir bool) {
+	rel, err := filepath.Rel(baseDir, canonicalPath(path))
+	inBaseDir = err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+	if parts := strings.Split(rel, string(filepath.Separator)); inBaseDir && len(parts) == 3 {
+		profileName, wsName = parts[0], parts[1]
+	}
+	return profileName, wsName, inBaseDir
+}
+
+// FixIssues repairs the fixable issues in place and marks them as fixed:
+// stale registrations are pruned, missing worktrees are re-linked or re-created
+// on their workspace branch, and, if deleteBranches is set, branches left without
+// a workspace are deleted when fully merged.
+func FixIssues(issues []DoctorIssue, deleteBranches bool) error {
+	var errs []error
+
+	// Prune once per repo, then check which registrations are gone
+	pruned := make(map[string]error)
+	for i := range issues {
+		issue := &issues[i]
+		if issue.Kind != IssueOrphan && issue.Kind != IssuePrunable {
+			continue
+		}
+		err, done := pruned[issue.RepoPath]
+		if !done {
+			err = WorktreePrune(issue.RepoPath)
+			pruned[issue.RepoPath] = err
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", issue.Repo, err))
+			}
+		}
+		if err == nil && !isRegisteredWorktree(issue.RepoPath, issue.Path) {
+			issue.Fixed = true
+		}
+	}
+
+	for i := range issues {
+		issue := &issues[i]
+		switch issue.Kind {
+		case IssueMissing:
+			if err := relinkWorktree(issue); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", issue.Label(), err))
+				continue
+			}
+			issue.Fixed = true
+		case IssueBranch:
+			if !deleteBranches {
+				continue
+			}
+			if err := BranchDelete(issue.RepoPath, issue.Branch, false); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", issue.Repo, err))
+				continue
+			}
+			issue.Fixed = true
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// relinkWorktree repairs the registration of an existing worktree directory,
+// or re-creates a missing worktree on its workspace branch.
+func relinkWorktree(issue *DoctorIssue) error {
+	if _, err := os.Stat(issue.Path); err == nil {
+		if err := WorktreeRepair(issue.RepoPath, issue.Path); err != nil {
+			return err
+		}
+		if !isRegisteredWorktree(issue.RepoPath, issue.Path) {
+			return fmt.Errorf("%s could not be re-linked; move it aside and run again to re-create it", issue.Path)
+		}
+		return nil
+	}
+
+	if issue.Branch == "" {
+		return fmt.Errorf("cannot re-create %s: its workspace branch is unknown", issue.Path)
+	}
+	if RefExists(issue.RepoPath, "refs/heads/"+issue.Branch) {
+		return WorktreeAddExisting(issue.RepoPath, issue.Path, issue.Branch)
+	}
+	return addWorktree(issue.RepoPath, issue.Path, WorktreeSource{Branch: issue.Branch, Base: issue.Base, Mode: CheckoutNew})
+}
+
+// findWorktree returns the registered worktree at path, or nil if there is none.
+func (r *doctorRepo) findWorktree(path string) *WorktreeEntry {
+	path = canonicalPath(path)
+	for i := range r.entries {
+		if canonicalPath(r.entries[i].Worktree) == path {
+			return &r.entries[i]
+		}
+	}
+	return nil
+}
+
+// checksOut reports whether a registered worktree has the branch checked out.
+func (r *doctorRepo) checksOut(branch string) bool {
+	for _, e := range r.entries {
+		if e.Branch == "refs/heads/"+branch {
+			return true
+		}
+	}
+	return false
+}
+
+// isRegisteredWorktree reports whether path is registered as a worktree of the repo.
+func isRegisteredWorktree(repoPath, path string) bool {
+	entries, err := WorktreeList(repoPath)
+	if err != nil {
+		return false
+	}
+	r := &doctorRepo{entries: entries}
+	return r.findWorktree(path) != nil
+}
+
+// canonicalPath resolves symlinks in the longest existing prefix of path, so paths
+// reported by git compare equal to paths built from the config.
+func canonicalPath(path string) string {
+	path = filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path
+	}
+	return filepath.Join(canonicalPath(parent), filepath.Base(path))
+}
@@ -0,0 +1,184 @@
+package mangrove
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// issueKinds returns the kinds of the issues in order.
+func issueKinds(issues []DoctorIssue) []string {
+	kinds := make([]string, len(issues))
+	for i, issue := range issues {
+		kinds[i] = issue.Kind
+	}
+	return kinds
+}
+
+func TestDiagnoseHealthy(t *testing.T) {
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := Profile{Repos: []Repo{{Name: "api", Path: newTestRepo(t)}}}
+	cfg.Profiles = map[string]Profile{"proj": profile}
+
+	if err := CreateWorkspace(cfg, &profile, "proj", "feature-x", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+
+	issues, err := Diagnose(cfg, "")
+	if err != nil {
+		t.Fatalf("Diagnose() unexpected error: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("Diagnose() = %+v, want no issues", issues)
+	}
+}
+
+func TestDiagnoseOrphanAndFix(t *testing.T) {
+	cfg := &Config{BaseDir: t.TempDir()}
+	repo := newTestRepo(t)
+	profile := Profile{Repos: []Repo{{Name: "api", Path: repo}}}
+	cfg.Profiles = map[string]Profile{"proj": profile}
+
+	if err := CreateWorkspace(cfg, &profile, "proj", "feature-x", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+
+	// Delete the workspace by hand, leaving the registration and branch behind
+	if err := os.RemoveAll(GetWorkspacePath(cfg, "proj", "feature-x")); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := Diagnose(cfg, "")
+	if err != nil {
+		t.Fatalf("Diagnose() unexpected error: %v", err)
+	}
+	kinds := issueKinds(issues)
+	if len(kinds) != 2 || kinds[0] != IssueOrphan || kinds[1] != IssueBranch {
+		t.Fatalf("Diagnose() kinds = %v, want [orphan branch]", kinds)
+	}
+	if issues[0].Profile != "proj" || issues[0].Workspace != "feature-x" || issues[1].Branch != "feature-x" {
+		t.Errorf("Diagnose() issues = %+v, want proj/feature-x on branch feature-x", issues)
+	}
+
+	if err := FixIssues(issues, true); err != nil {
+		t.Fatalf("FixIssues() unexpected error: %v", err)
+	}
+	for _, issue := range issues {
+		if !issue.Fixed {
+			t.Errorf("%s issue not fixed", issue.Kind)
+		}
+	}
+
+	entries, err := WorktreeList(repo)
+	if err != nil {
+		t.Fatalf("WorktreeList() unexpected error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("repo has %d worktrees after fix, want 1", len(entries))
+	}
+	if RefExists(repo, "refs/heads/feature-x") {
+		t.Error("branch feature-x should be deleted")
+	}
+}
+
+func TestDiagnoseMissingAndFix(t *testing.T) {
+	cfg := &Config{BaseDir: t.TempDir()}
+	repo := newTestRepo(t)
+	profile := Profile{Repos: []Repo{{Name: "api", Path: repo}}}
+	cfg.Profiles = map[string]Profile{"proj": profile}
+
+	if err := CreateWorkspace(cfg, &profile, "proj", "feature-x", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	wtDir := filepath.Join(GetWorkspacePath(cfg, "proj", "feature-x"), "api")
+	runGit(t, repo, "worktree", "remove", wtDir)
+
+	issues, err := Diagnose(cfg, "proj")
+	if err != nil {
+		t.Fatalf("Diagnose() unexpected error: %v", err)
+	}
+	if kinds := issueKinds(issues); len(kinds) != 1 || kinds[0] != IssueMissing {
+		t.Fatalf("Diagnose() kinds = %v, want [missing]", kinds)
+	}
+
+	if err := FixIssues(issues, false); err != nil {
+		t.Fatalf("FixIssues() unexpected error: %v", err)
+	}
+	branch, err := CurrentBranch(wtDir)
+	if err != nil {
+		t.Fatalf("CurrentBranch() unexpected error: %v", err)
+	}
+	if branch != "feature-x" {
+		t.Errorf("re-created worktree branch = %q, want feature-x", branch)
+	}
+}
+
+func TestDiagnoseLocked(t *testing.T) {
+	cfg := &Config{BaseDir: t.TempDir()}
+	repo := newTestRepo(t)
+	profile := Profile{Repos: []Repo{{Name: "api", Path: repo}}}
+	cfg.Profiles = map[string]Profile{"proj": profile}
+
+	if err := CreateWorkspace(cfg, &profile, "proj", "feature-x", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	wtDir := filepath.Join(GetWorkspacePath(cfg, "proj", "feature-x"), "api")
+	runGit(t, repo, "worktree", "lock", "--reason", "on usb drive", wtDir)
+
+	issues, err := Diagnose(cfg, "")
+	if err != nil {
+		t.Fatalf("Diagnose() unexpected error: %v", err)
+	}
+	if len(issues) != 1 || issues[0].Kind != IssueLocked {
+		t.Fatalf("Diagnose() = %+v, want one locked issue", issues)
+	}
+	if issues[0].Fixable(true) {
+		t.Error("locked issue should not be fixable")
+	}
+}
+
+func TestDiagnoseLeftoverBranch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	cfg := &Config{BaseDir: t.TempDir()}
+	repo := newTestRepo(t)
+	profile := Profile{Repos: []Repo{{Name: "api", Path: repo}}}
+	cfg.Profiles = map[string]Profile{"proj": profile}
+
+	for _, name := range []string{"gone", "shelved"} {
+		if err := CreateWorkspace(cfg, &profile, "proj", name, nil); err != nil {
+			t.Fatalf("CreateWorkspace(%s) unexpected error: %v", name, err)
+		}
+	}
+
+	// Delete one workspace by hand and prune its registration, so only the branch
+	// is left; archive the other, which keeps its branch on purpose
+	if err := os.RemoveAll(GetWorkspacePath(cfg, "proj", "gone")); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "worktree", "prune")
+	if err := ArchiveWorkspace(cfg, &profile, "proj", "shelved"); err != nil {
+		t.Fatalf("ArchiveWorkspace() unexpected error: %v", err)
+	}
+
+	issues, err := Diagnose(cfg, "")
+	if err != nil {
+		t.Fatalf("Diagnose() unexpected error: %v", err)
+	}
+	if kinds := issueKinds(issues); len(kinds) != 1 || kinds[0] != IssueBranch {
+		t.Fatalf("Diagnose() kinds = %v, want [branch]", kinds)
+	}
+	if issues[0].Branch != "gone" || issues[0].Profile != "proj" || issues[0].Workspace != "gone" {
+		t.Errorf("Diagnose() issue = %+v, want branch gone of proj/gone", issues[0])
+	}
+
+	if err := FixIssues(issues, true); err != nil {
+		t.Fatalf("FixIssues() unexpected error: %v", err)
+	}
+	if RefExists(repo, "refs/heads/gone") {
+		t.Error("branch gone should be deleted")
+	}
+	if !RefExists(repo, "refs/heads/shelved") {
+		t.Error("archived branch shelved should be kept")
+	}
+}
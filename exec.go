@@ -0,0 +1,136 @@
+package mangrove
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ExecTarget is a directory to run a command in, labelled by repo name.
+type ExecTarget struct {
+	Name string
+	Dir  string
+}
+
+// ExecResult is the outcome of running a command in one repo.
+type ExecResult struct {
+	RepoResult
+	ExitCode int
+	Duration time.Duration
+}
+
+// prefixColors are cycled through for line prefixes, like docker compose logs.
+var prefixColors = []lipgloss.Color{"6", "5", "3", "2", "4", "1"}
+
+// ExecSerial runs the command in each target one after another with stdin,
+// stdout and stderr attached, printing a header before each repo.
+func ExecSerial(targets []ExecTarget, args []string) []ExecResult {
+	results := make([]ExecResult, len(targets))
+	for i, t := range targets {
+		fmt.Fprintf(os.Stderr, "\n[%s]\n", RepoNameStyle.Render(t.Name))
+
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = t.Dir
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Stdin = os.Stdin
+
+		results[i] = runExec(t.Name, cmd)
+	}
+	return results
+}
+
+// ExecParallel runs the command in up to limit targets at once. Output is
+// interleaved live, with every line prefixed by the repo name. Stdin is not attached.
+func ExecParallel(targets []ExecTarget, args []string, limit int, stdout, stderr io.Writer) []ExecResult {
+	width := 0
+	for _, t := range targets {
+		width = max(width, len(t.Name))
+	}
+
+	var mu sync.Mutex
+	results := make([]ExecResult, len(targets))
+
+	RunParallel(len(targets), limit, func(i int) {
+		t := targets[i]
+		style := lipgloss.NewStyle().Foreground(prefixColors[i%len(prefixColors)])
+		prefix := style.Render(fmt.Sprintf("[%-*s]", width, t.Name)) + " "
+
+		out := &prefixWriter{mu: &mu, w: stdout, prefix: prefix}
+		errOut := &prefixWriter{mu: &mu, w: stderr, prefix: prefix}
+
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = t.Dir
+		cmd.Stdout = out
+		cmd.Stderr = errOut
+
+		results[i] = runExec(t.Name, cmd)
+		out.Flush()
+		errOut.Flush()
+	})
+
+	return results
+}
+
+// runExec runs cmd and records its exit code and duration.
+func runExec(name string, cmd *exec.Cmd) ExecResult {
+	start := time.Now()
+	err := cmd.Run()
+	result := ExecResult{RepoResult: RepoResult{Name: name, Err: err}, Duration: time.Since(start)}
+	result.setError()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		result.ExitCode = 0
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		result.ExitCode = -1
+	}
+	return result
+}
+
+// prefixWriter writes each complete line to w with a prefix.
+// Writers sharing mu never interleave within a line.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.buf = append(p.buf, data...)
+	for {
+		idx := bytes.IndexByte(p.buf, '\n')
+		if idx < 0 {
+			break
+		}
+		p.writeLine(p.buf[:idx+1])
+		p.buf = p.buf[idx+1:]
+	}
+	return len(data), nil
+}
+
+// Flush writes any trailing partial line.
+func (p *prefixWriter) Flush() {
+	if len(p.buf) == 0 {
+		return
+	}
+	p.writeLine(append(p.buf, '\n'))
+	p.buf = nil
+}
+
+func (p *prefixWriter) writeLine(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "%s%s", p.prefix, line)
+}
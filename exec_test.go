@@ -0,0 +1,66 @@
+package mangrove
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	var mu sync.Mutex
+	var buf bytes.Buffer
+	w := &prefixWriter{mu: &mu, w: &buf, prefix: "[api] "}
+
+	_, _ = w.Write([]byte("first li"))
+	_, _ = w.Write([]byte("ne\nsecond line\nthird"))
+	if got := buf.String(); got != "[api] first line\n[api] second line\n" {
+		t.Errorf("before flush = %q", got)
+	}
+
+	w.Flush()
+	if got := buf.String(); got != "[api] first line\n[api] second line\n[api] third\n" {
+		t.Errorf("after flush = %q", got)
+	}
+}
+
+func TestExecParallel(t *testing.T) {
+	targets := []ExecTarget{
+		{Name: "ok", Dir: t.TempDir()},
+		{Name: "fails", Dir: t.TempDir()},
+	}
+	script := []string{"sh", "-c", `echo "out $(basename $PWD)"; echo err >&2; [ "$FAIL_IN" != "$PWD" ]`}
+	t.Setenv("FAIL_IN", targets[1].Dir)
+
+	var stdout, stderr bytes.Buffer
+	results := ExecParallel(targets, script, 2, &stdout, &stderr)
+
+	if len(results) != 2 {
+		t.Fatalf("ExecParallel() returned %d results, want 2", len(results))
+	}
+	if results[0].Name != "ok" || results[0].ExitCode != 0 || results[0].Err != nil {
+		t.Errorf("results[0] = %+v, want ok with exit 0", results[0])
+	}
+	if results[1].Name != "fails" || results[1].ExitCode != 1 || results[1].Err == nil {
+		t.Errorf("results[1] = %+v, want fails with exit 1", results[1])
+	}
+	if got := CountFailed(results); got != 1 {
+		t.Errorf("CountFailed() = %d, want 1", got)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		if !strings.Contains(line, "[ok   ] out") && !strings.Contains(line, "[fails] out") {
+			t.Errorf("stdout line %q is not prefixed with a padded repo name", line)
+		}
+	}
+	if n := strings.Count(stderr.String(), "err\n"); n != 2 {
+		t.Errorf("stderr has %d prefixed lines, want 2:\n%s", n, stderr.String())
+	}
+}
+
+func TestExecParallelCommandNotFound(t *testing.T) {
+	results := ExecParallel([]ExecTarget{{Name: "api", Dir: t.TempDir()}}, []string{"mgv-command-that-does-not-exist"}, 1, &bytes.Buffer{}, &bytes.Buffer{})
+	if results[0].Err == nil || results[0].ExitCode != -1 {
+		t.Errorf("result = %+v, want error with exit code -1", results[0])
+	}
+}
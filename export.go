@@ -0,0 +1,195 @@
+package mangrove
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExportManifestFile is the name of the manifest written to an export directory.
+const ExportManifestFile = "manifest.yaml"
+
+// ExportedRepo describes the patch series of one repo in an export.
+// Patches are paths relative to the export directory, in the order to apply them.
+type ExportedRepo struct {
+	Name       string   `yaml:"name"              json:"name"`
+	Branch     string   `yaml:"branch"            json:"branch"`
+	Base       string   `yaml:"base"              json:"base"`
+	BaseCommit string   `yaml:"base_commit"       json:"base_commit"`
+	Patches    []string `yaml:"patches,omitempty" json:"patches,omitempty"`
+}
+
+// ExportManifest maps the patch series in an export directory to profile repo names.
+type ExportManifest struct {
+	Profile    string         `yaml:"profile"     json:"profile"`
+	Workspace  string         `yaml:"workspace"   json:"workspace"`
+	ExportedAt time.Time      `yaml:"exported_at" json:"exported_at"`
+	Repos      []ExportedRepo `yaml:"repos"       json:"repos"`
+}
+
+// ExportWorkspace writes git format-patch output for each repo's commits since its
+// base into outDir/<repo>/, plus a manifest mapping the patches to repo names.
+// Uncommitted changes are not exported.
+func ExportWorkspace(cfg *Config, profile *Profile, profileName, name, outDir string) (*ExportManifest, error) {
+	// git runs in each worktree, so relative paths would land there
+	outDir, err := filepath.Abs(outDir)
+	if err != nil {
+		return nil, err
+	}
+
+	wsPath := GetWorkspacePath(cfg, profileName, name)
+	if _, err := os.Stat(wsPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("workspace %q not found at %s", name, wsPath)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, ExportManifestFile)); err == nil {
+		return nil, fmt.Errorf("%s already contains an export", outDir)
+	}
+
+	meta, err := LoadWorkspaceMetadata(wsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &ExportManifest{
+		Profile:    profileName,
+		Workspace:  name,
+		ExportedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	for _, repo := range WorkspaceRepos(meta, profile) {
+		wtDir := filepath.Join(wsPath, repo.Name)
+		if _, err := os.Stat(wtDir); os.IsNotExist(err) {
+			PrintWarning("Skipping %s: directory not found", repo.Name)
+			continue
+		}
+
+		er := ExportedRepo{Name: repo.Name, Base: meta.RepoBase(&repo)}
+		if er.Branch, err = CurrentBranch(wtDir); err != nil {
+			return nil, fmt.Errorf("%s: %w", repo.Name, err)
+		}
+		if er.BaseCommit, err = MergeBase(wtDir, er.Base, "HEAD"); err != nil {
+			return nil, fmt.Errorf("%s: %w", repo.Name, err)
+		}
+
+		files, err := FormatPatch(wtDir, er.BaseCommit+"..HEAD", filepath.Join(outDir, repo.Name))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", repo.Name, err)
+		}
+		for _, f := range files {
+			er.Patches = append(er.Patches, filepath.Join(repo.Name, filepath.Base(f)))
+		}
+		manifest.Repos = append(manifest.Repos, er)
+
+		if count, err := StatusChangedCount(wtDir); err == nil && count > 0 {
+			PrintWarning("%s has uncommitted changes (%d files) that are not exported", repo.Name, count)
+		}
+		PrintSuccess("%s  %d patch(es)", RepoNameStyle.Render(repo.Name), len(er.Patches))
+	}
+
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outDir, ExportManifestFile), data, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// LoadExportManifest reads the manifest of an export directory.
+func LoadExportManifest(dir string) (*ExportManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ExportManifestFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%s is not an mgv export: %s not found", dir, ExportManifestFile)
+		}
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest ExportManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// ImportWorkspace creates a new workspace from an export directory and applies each
+// repo's patch series with git am. Repos are matched to the profile by name. Each
+// branch starts at the exported base commit if it exists locally, otherwise at the
+// base branch. If a series does not apply, git am is aborted in that repo and the
+// workspace is kept so the patches can be applied by hand.
+func ImportWorkspace(cfg *Config, profile *Profile, profileName, name, dir string) error {
+	if err := ValidateWorkspaceName(name); err != nil {
+		return err
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	manifest, err := LoadExportManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	subset := *profile
+	subset.Repos = nil
+	sources := make(map[string]WorktreeSource)
+	var missing []string
+	for _, er := range manifest.Repos {
+		repo := FindRepo(profile.Repos, er.Name)
+		if repo == nil {
+			missing = append(missing, er.Name)
+			continue
+		}
+		subset.Repos = append(subset.Repos, *repo)
+
+		src := WorktreeSource{Base: er.Base, Mode: CheckoutNew}
+		if _, err := RevParse(repo.Path, er.BaseCommit); err == nil {
+			src.StartPoint = er.BaseCommit
+		}
+		sources[er.Name] = src
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("repos not in profile %q: %v", profileName, missing)
+	}
+	if len(subset.Repos) == 0 {
+		return fmt.Errorf("the export in %s has no repos", dir)
+	}
+
+	if err := CreateWorkspace(cfg, &subset, profileName, name, sources); err != nil {
+		return err
+	}
+
+	wsPath := GetWorkspacePath(cfg, profileName, name)
+	fmt.Fprintf(os.Stderr, "\nApplying patches\n")
+
+	var errs []error
+	for _, er := range manifest.Repos {
+		if len(er.Patches) == 0 {
+			continue
+		}
+		wtDir := filepath.Join(wsPath, er.Name)
+		files := make([]string, len(er.Patches))
+		for i, p := range er.Patches {
+			files[i] = filepath.Join(dir, p)
+		}
+		if err := Am(wtDir, files); err != nil {
+			_ = AmAbort(wtDir)
+			errs = append(errs, fmt.Errorf("%s: %w", er.Name, err))
+			continue
+		}
+		PrintSuccess("%s  applied %d patch(es)", RepoNameStyle.Render(er.Name), len(files))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%w\nthe workspace is kept at %s", err, wsPath)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package mangrove
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportAndImportWorkspace(t *testing.T) {
+	repos := []Repo{
+		{Name: "api", Path: newTestRepo(t)},
+		{Name: "web", Path: newTestRepo(t)},
+	}
+
+	// Another machine with its own clones of the same repos
+	var clones []Repo
+	for _, repo := range repos {
+		dir := filepath.Join(t.TempDir(), repo.Name)
+		runGit(t, filepath.Dir(dir), "clone", "-q", repo.Path, dir)
+		clones = append(clones, Repo{Name: repo.Name, Path: dir})
+	}
+
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos}
+	if err := CreateWorkspace(cfg, profile, "proj", "feat", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	apiDir := filepath.Join(GetWorkspacePath(cfg, "proj", "feat"), "api")
+	writeAndCommit(t, apiDir, "one.txt", "1\n", "first change")
+	writeAndCommit(t, apiDir, "two.txt", "2\n", "second change")
+
+	outDir := filepath.Join(t.TempDir(), "export")
+	manifest, err := ExportWorkspace(cfg, profile, "proj", "feat", outDir)
+	if err != nil {
+		t.Fatalf("ExportWorkspace() unexpected error: %v", err)
+	}
+	if len(manifest.Repos) != 2 || len(manifest.Repos[0].Patches) != 2 || len(manifest.Repos[1].Patches) != 0 {
+		t.Fatalf("manifest = %+v, want 2 api patches and none for web", manifest)
+	}
+	for _, p := range manifest.Repos[0].Patches {
+		if _, err := os.Stat(filepath.Join(outDir, p)); err != nil {
+			t.Errorf("patch %s missing: %v", p, err)
+		}
+	}
+	if _, err := ExportWorkspace(cfg, profile, "proj", "feat", outDir); err == nil {
+		t.Error("exporting into an existing export should fail")
+	}
+
+	otherCfg := &Config{BaseDir: t.TempDir()}
+	otherProfile := &Profile{Repos: clones}
+	if err := ImportWorkspace(otherCfg, otherProfile, "proj", "review", outDir); err != nil {
+		t.Fatalf("ImportWorkspace() unexpected error: %v", err)
+	}
+
+	wsPath := GetWorkspacePath(otherCfg, "proj", "review")
+	importedAPI := filepath.Join(wsPath, "api")
+	if branch, _ := CurrentBranch(importedAPI); branch != "review" {
+		t.Errorf("imported branch = %q, want review", branch)
+	}
+	if log := runGit(t, importedAPI, "log", "--format=%s", "main..HEAD"); log != "second change\nfirst change" {
+		t.Errorf("imported commits = %q, want both changes", log)
+	}
+	if _, err := os.Stat(filepath.Join(wsPath, "web")); err != nil {
+		t.Errorf("web worktree missing: %v", err)
+	}
+
+	// A profile without one of the repos cannot import the export
+	err = ImportWorkspace(otherCfg, &Profile{Repos: clones[:1]}, "proj", "other", outDir)
+	if err == nil || !strings.Contains(err.Error(), "web") {
+		t.Errorf("ImportWorkspace() error = %v, want missing repo web", err)
+	}
+
+	// A name that escapes the profile directory is rejected before anything is created
+	err = ImportWorkspace(otherCfg, otherProfile, "proj", "../escape", outDir)
+	if err == nil || !strings.Contains(err.Error(), "invalid workspace name") {
+		t.Errorf("ImportWorkspace() error = %v, want an invalid name", err)
+	}
+	if _, err := os.Stat(filepath.Join(otherCfg.BaseDir, "escape")); !os.IsNotExist(err) {
+		t.Errorf("nothing should be created outside the profile directory")
+	}
+}
+
+func TestFormatPatch(t *testing.T) {
+	repo := newTestRepo(t)
+	writeAndCommit(t, repo, "a.txt", "a\n", "first change")
+	writeAndCommit(t, repo, "b.txt", "b\n", "second change")
+
+	outDir := t.TempDir()
+	files, err := FormatPatch(repo, "HEAD~2..HEAD", outDir)
+	if err != nil {
+		t.Fatalf("FormatPatch() unexpected error: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("FormatPatch() = %q, want 2 patch files", files)
+	}
+	for _, f := range files {
+		if _, err := os.Stat(f); err != nil || !strings.HasSuffix(f, ".patch") {
+			t.Errorf("FormatPatch() returned %q, want an existing patch file", f)
+		}
+	}
+
+	// git's error output ends up in the error, not in the file list
+	if _, err := FormatPatch(repo, "no-such-rev..HEAD", outDir); err == nil || !strings.Contains(err.Error(), "no-such-rev") {
+		t.Errorf("FormatPatch() error = %v, want git's message", err)
+	}
+}
@@ -0,0 +1,98 @@
+package mangrove
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ForkWorkspace creates a new workspace from an existing one. Each repo gets a new
+// branch (named by branch_template) starting at the current HEAD of the source
+// worktree, keeps the source's base branch, and the source is recorded as the
+// parent. With carryChanges, uncommitted changes (including untracked files) are
+// copied to the new workspace through the stash and left in place in the source.
+func ForkWorkspace(cfg *Config, profile *Profile, profileName, srcName, dstName string, carryChanges bool) error {
+	if err := ValidateWorkspaceName(dstName); err != nil {
+		return err
+	}
+
+	srcPath := GetWorkspacePath(cfg, profileName, srcName)
+	if _, err := os.Stat(srcPath); os.IsNotExist(err) {
+		return fmt.Errorf("workspace %q not found at %s", srcName, srcPath)
+	}
+
+	srcMeta, err := LoadWorkspaceMetadata(srcPath)
+	if err != nil {
+		return err
+	}
+
+	subset := *profile
+	subset.Repos = WorkspaceRepos(srcMeta, profile)
+
+	sources := make(map[string]WorktreeSource)
+	for _, repo := range subset.Repos {
+		head, err := RevParse(filepath.Join(srcPath, repo.Name), "HEAD")
+		if err != nil {
+			return fmt.Errorf("%s: %w", repo.Name, err)
+		}
+		sources[repo.Name] = WorktreeSource{
+			Base:       srcMeta.RepoBase(&repo),
+			Mode:       CheckoutNew,
+			StartPoint: head,
+		}
+	}
+
+	if err := CreateWorkspace(cfg, &subset, profileName, dstName, sources); err != nil {
+		return err
+	}
+
+	dstPath := GetWorkspacePath(cfg, profileName, dstName)
+	meta, err := LoadWorkspaceMetadata(dstPath)
+	if err != nil {
+		return err
+	}
+	meta.Parent = srcName
+	if err := SaveWorkspaceMetadata(dstPath, meta); err != nil {
+		return err
+	}
+
+	if !carryChanges {
+		return nil
+	}
+
+	var errs []error
+	for _, repo := range subset.Repos {
+		if err := carryOver(repo.Name, filepath.Join(srcPath, repo.Name), filepath.Join(dstPath, repo.Name), dstName); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", repo.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// carryOver copies the uncommitted changes of one worktree to another by stashing
+// them in src, applying the stash in dst, and popping it back in src.
+func carryOver(repoName, srcDir, dstDir, dstName string) error {
+	count, err := StatusChangedCount(srcDir)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	if err := StashPushUntracked(srcDir, "mgv-fork: "+dstName); err != nil {
+		return err
+	}
+
+	applyErr := StashApply(dstDir)
+	if err := StashPop(srcDir); err != nil {
+		return errors.Join(applyErr, fmt.Errorf("changes are kept in the stash: %w", err))
+	}
+	if applyErr != nil {
+		return applyErr
+	}
+
+	PrintSuccess("%s  carried over %d changed files", RepoNameStyle.Render(repoName), count)
+	return nil
+}
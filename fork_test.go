@@ -0,0 +1,155 @@
+package mangrove
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestForkWorkspace(t *testing.T) {
+	repos := []Repo{
+		{Name: "api", Path: newTestRepo(t)},
+		{Name: "web", Path: newTestRepo(t)},
+	}
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "src", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	srcPath := GetWorkspacePath(cfg, "proj", "src")
+	srcAPI := filepath.Join(srcPath, "api")
+
+	// Committed work, an uncommitted change and an untracked file in api
+	writeAndCommit(t, srcAPI, "feature.txt", "v1\n", "add feature")
+	if err := os.WriteFile(filepath.Join(srcAPI, "feature.txt"), []byte("v2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcAPI, "scratch.txt"), []byte("wip\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	srcHead, _ := RevParse(srcAPI, "HEAD")
+
+	if err := ForkWorkspace(cfg, profile, "proj", "src", "dst", true); err != nil {
+		t.Fatalf("ForkWorkspace() unexpected error: %v", err)
+	}
+
+	dstAPI := filepath.Join(GetWorkspacePath(cfg, "proj", "dst"), "api")
+	branch, err := CurrentBranch(dstAPI)
+	if err != nil {
+		t.Fatalf("CurrentBranch() unexpected error: %v", err)
+	}
+	if branch != "dst" {
+		t.Errorf("fork branch = %q, want dst", branch)
+	}
+	if head, _ := RevParse(dstAPI, "HEAD"); head != srcHead {
+		t.Errorf("fork HEAD = %s, want source HEAD %s", head, srcHead)
+	}
+
+	// Changes are in both workspaces
+	for _, dir := range []string{srcAPI, dstAPI} {
+		data, err := os.ReadFile(filepath.Join(dir, "feature.txt"))
+		if err != nil || string(data) != "v2\n" {
+			t.Errorf("%s feature.txt = %q, %v; want uncommitted v2", dir, data, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "scratch.txt")); err != nil {
+			t.Errorf("%s scratch.txt missing: %v", dir, err)
+		}
+	}
+	if out := runGit(t, repos[0].Path, "stash", "list"); out != "" {
+		t.Errorf("stash should be empty after fork, got %q", out)
+	}
+
+	meta, err := LoadWorkspaceMetadata(GetWorkspacePath(cfg, "proj", "dst"))
+	if err != nil || meta == nil {
+		t.Fatalf("LoadWorkspaceMetadata() = %v, %v; want metadata", meta, err)
+	}
+	if meta.Parent != "src" {
+		t.Errorf("metadata parent = %q, want src", meta.Parent)
+	}
+	if rm := meta.FindRepo("api"); rm == nil || rm.Base != "main" {
+		t.Errorf("api metadata = %+v, want base main", rm)
+	}
+}
+
+func TestForkWorkspaceAdHocRepo(t *testing.T) {
+	repos := []Repo{{Name: "api", Path: newTestRepo(t)}}
+	profile := &Profile{Repos: repos}
+	cfg := &Config{BaseDir: t.TempDir(), Profiles: map[string]Profile{"proj": *profile}}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "src", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	adhoc := Repo{Name: "docs", Path: newTestRepo(t), DefaultBase: "main"}
+	if err := AddRepos(cfg, profile, "proj", "src", []Repo{adhoc}, nil); err != nil {
+		t.Fatalf("AddRepos() unexpected error: %v", err)
+	}
+
+	if err := ForkWorkspace(cfg, profile, "proj", "src", "dst", false); err != nil {
+		t.Fatalf("ForkWorkspace() unexpected error: %v", err)
+	}
+
+	meta, err := LoadWorkspaceMetadata(GetWorkspacePath(cfg, "proj", "dst"))
+	if err != nil || meta == nil {
+		t.Fatalf("LoadWorkspaceMetadata() = %v, %v; want metadata", meta, err)
+	}
+	if rm := meta.FindRepo("api"); rm == nil || rm.Path != "" {
+		t.Errorf("api metadata = %+v, want a profile repo without path", rm)
+	}
+	if rm := meta.FindRepo("docs"); rm == nil || rm.Path != adhoc.Path {
+		t.Errorf("docs metadata = %+v, want path %s", rm, adhoc.Path)
+	}
+	if got := WorkspaceRepos(meta, profile); len(got) != 2 {
+		t.Errorf("WorkspaceRepos() = %+v, want api and docs", got)
+	}
+}
+
+func TestForkWorkspaceWithoutChanges(t *testing.T) {
+	repos := []Repo{{Name: "api", Path: newTestRepo(t)}}
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "src", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	srcAPI := filepath.Join(GetWorkspacePath(cfg, "proj", "src"), "api")
+	if err := os.WriteFile(filepath.Join(srcAPI, "README.md"), []byte("changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ForkWorkspace(cfg, profile, "proj", "src", "dst", false); err != nil {
+		t.Fatalf("ForkWorkspace() unexpected error: %v", err)
+	}
+
+	dstAPI := filepath.Join(GetWorkspacePath(cfg, "proj", "dst"), "api")
+	if count, _ := StatusChangedCount(dstAPI); count != 0 {
+		t.Errorf("fork has %d changed files, want a clean worktree", count)
+	}
+	if count, _ := StatusChangedCount(srcAPI); count != 1 {
+		t.Errorf("source has %d changed files, want 1", count)
+	}
+}
+
+func TestForkWorkspaceInvalidName(t *testing.T) {
+	repo := newTestRepo(t)
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: []Repo{{Name: "api", Path: repo}}}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "src", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	srcAPI := filepath.Join(GetWorkspacePath(cfg, "proj", "src"), "api")
+	if err := os.WriteFile(filepath.Join(srcAPI, "README.md"), []byte("changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ForkWorkspace(cfg, profile, "proj", "src", "../escape", true); err == nil {
+		t.Fatal("ForkWorkspace() expected error for an invalid name, got nil")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.BaseDir, "escape")); !os.IsNotExist(err) {
+		t.Errorf("no workspace should be created outside the profile directory, stat err = %v", err)
+	}
+	if count, _ := StatusChangedCount(srcAPI); count != 1 {
+		t.Errorf("source has %d changed files, want 1", count)
+	}
+}
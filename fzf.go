@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
@@ -61,6 +62,59 @@ func SelectWithFzf(items []string, prompt, header string) (string, error) {
 	return selected, nil
 }
 
+// SelectMultiWithFzf presents a list of items via fzf and lets the user select several
+// with Tab. Returns the selected items in the order fzf prints them.
+func SelectMultiWithFzf(items []string, prompt, header string) ([]string, error) {
+	if !IsFzfAvailable() {
+		return nil, fmt.Errorf("fzf is not installed. Install it with: brew install fzf")
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no items to select from")
+	}
+
+	args := []string{"--multi"}
+	if prompt != "" {
+		args = append(args, "--prompt", prompt+" ")
+	}
+	if header != "" {
+		args = append(args, "--header", header)
+	}
+	args = append(args, "--height", "~40%", "--reverse")
+
+	cmd := exec.Command("fzf", args...)
+	cmd.Stdin = strings.NewReader(strings.Join(items, "\n"))
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if exitErr.ExitCode() == 130 || exitErr.ExitCode() == 1 {
+				return nil, fmt.Errorf("%w", ErrCancelled)
+			}
+		}
+		return nil, fmt.Errorf("fzf selection failed: %w", err)
+	}
+
+	selected := parseLines(string(output))
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no item selected")
+	}
+
+	return selected, nil
+}
+
+// SelectRepos lets the user pick several repos via fzf.
+func SelectRepos(names []string) ([]string, error) {
+	return SelectMultiWithFzf(names, "Repos:", "Select repos (Tab to toggle, Enter to confirm)")
+}
+
+// SelectFiles lets the user pick several changed files of a repo via fzf.
+// Items are "XY path" lines as printed by git status --porcelain.
+func SelectFiles(repoName string, items []string) ([]string, error) {
+	return SelectMultiWithFzf(items, repoName+":", "Select files to commit (Tab to toggle, Enter to confirm, Esc to skip)")
+}
+
 // SelectDirectory lets the user pick a directory using fzf's directory walker.
 // walkerRoot sets the starting directory for browsing. If empty, defaults to the user's home directory.
 func SelectDirectory(prompt, walkerRoot string) (string, error) {
@@ -210,13 +264,19 @@ func SelectProfile(names []string) (string, error) {
 	return SelectWithFzf(names, "Profile:", "Select profile")
 }
 
-// SelectMethod lets the user choose the apply method (stash, merge, or skip) via fzf.
+// SelectMethod lets the user choose the apply method (one of ApplyMethods, or skip) via fzf.
 func SelectMethod(repoName string) (string, error) {
-	items := []string{"stash", "merge", "skip"}
-	header := fmt.Sprintf("[%s] stash=未コミット変更を反映 / merge=コミット済み変更をマージ / skip=スキップ", repoName)
+	items := append(slices.Clone(ApplyMethods), "skip")
+	header := fmt.Sprintf("[%s] stash=未コミット変更を反映 / merge=コミット済み変更をマージ / rebase=コミットを派生元に積み直し / squash=1 コミットにまとめる / cherry-pick=コミットを選んで反映 / skip=スキップ", repoName)
 	return SelectWithFzf(items, "Method:", header)
 }
 
+// SelectCommits lets the user pick several commits of a repo via fzf.
+// Items are "<short hash> <subject>" lines.
+func SelectCommits(repoName string, items []string) ([]string, error) {
+	return SelectMultiWithFzf(items, repoName+":", "Select commits to cherry-pick (Tab to toggle, Enter to confirm)")
+}
+
 // reorderWithDefault moves the defaultItem to the front of the list.
 func reorderWithDefault(items []string, defaultItem string) []string {
 	if defaultItem == "" {
@@ -0,0 +1,169 @@
+package mangrove
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GC reasons.
+const (
+	GCMerged = "merged"
+	GCIdle   = "idle"
+)
+
+// GCOptions selects which workspaces FindGCCandidates reports.
+type GCOptions struct {
+	// Merged reports workspaces whose branches are fully merged into their base in every repo.
+	Merged bool
+	// IdleFor reports clean workspaces without a commit for at least this long. Zero disables it.
+	IdleFor time.Duration
+}
+
+// GCCandidate is a workspace that can be garbage collected.
+type GCCandidate struct {
+	Profile    string    `json:"profile"     yaml:"profile"`
+	Workspace  string    `json:"name"        yaml:"name"`
+	Path       string    `json:"path"        yaml:"path"`
+	Reasons    []string  `json:"reasons"     yaml:"reasons"`
+	LastActive time.Time `json:"last_active" yaml:"last_active"`
+}
+
+// FindGCCandidates returns the workspaces that match opts, sorted by profile and name.
+// A workspace is only reported if every repo worktree exists, has no uncommitted
+// changes and no sync is in progress, so removing it loses nothing but the worktrees.
+// If profileName is empty, all profiles are scanned.
+func FindGCCandidates(cfg *Config, profileName string, opts GCOptions) ([]GCCandidate, error) {
+	workspaces, err := ListWorkspaces(cfg, profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var candidates []GCCandidate
+	for _, ws := range workspaces {
+		if !gcEligible(&ws) {
+			continue
+		}
+
+		c := GCCandidate{
+			Profile:    ws.ProfileName,
+			Workspace:  ws.WorkspaceName,
+			Path:       ws.Path,
+			LastActive: workspaceLastActive(&ws),
+		}
+		if opts.Merged && workspaceMerged(&ws) {
+			c.Reasons = append(c.Reasons, GCMerged)
+		}
+		if opts.IdleFor > 0 && !c.LastActive.IsZero() && now.Sub(c.LastActive) >= opts.IdleFor {
+			c.Reasons = append(c.Reasons, GCIdle)
+		}
+		if len(c.Reasons) > 0 {
+			candidates = append(candidates, c)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Profile != candidates[j].Profile {
+			return candidates[i].Profile < candidates[j].Profile
+		}
+		return candidates[i].Workspace < candidates[j].Workspace
+	})
+	return candidates, nil
+}
+
+// gcEligible reports whether a workspace is safe to remove: every worktree exists
+// and is clean, and no sync or apply is in progress. A worktree whose status cannot
+// be read is not known to be clean.
+func gcEligible(ws *WorkspaceInfo) bool {
+	if len(ws.RepoStatuses) == 0 {
+		return false
+	}
+	if state, err := LoadSyncState(ws.Path); err != nil || state != nil {
+		return false
+	}
+	if state, err := LoadApplyState(ws.Path); err != nil || state != nil {
+		return false
+	}
+	for _, rs := range ws.RepoStatuses {
+		if !rs.Exists {
+			return false
+		}
+		if count, err := StatusChangedCount(filepath.Join(ws.Path, rs.RepoName)); err != nil || count > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// workspaceMerged reports whether every repo's branch is merged into its base
+// (locally or on origin) and at least one repo has commits of its own, so that
+// freshly created or only synced workspaces are not reported.
+func workspaceMerged(ws *WorkspaceInfo) bool {
+	worked := false
+	for _, rs := range ws.RepoStatuses {
+		wtDir := filepath.Join(ws.Path, rs.RepoName)
+		into := mergedInto(wtDir, rs.DefaultBase)
+		if into == "" {
+			return false
+		}
+		if !worked && branchWorked(wtDir, rs.BranchName, into) {
+			worked = true
+		}
+	}
+	return worked
+}
+
+// mergedInto returns base or origin/base, whichever the HEAD of a worktree is merged
+// into, or "" if it is merged into neither.
+func mergedInto(wtDir, base string) string {
+	if n, err := UnmergedCount(wtDir, base, "HEAD"); err == nil && n == 0 {
+		return base
+	}
+	upstream := syncUpstream(wtDir, base)
+	if upstream == base {
+		return ""
+	}
+	if n, err := UnmergedCount(wtDir, upstream, "HEAD"); err == nil && n == 0 {
+		return upstream
+	}
+	return ""
+}
+
+// branchWorked reports whether a branch merged into into has commits of its own:
+// commits into has only as patches (squashed, cherry-picked or rebased), or commits
+// its reflog shows were made on it. A branch that was only created or fast-forwarded
+// by a sync has none, and without a reflog (e.g. in a bare repo) a branch whose
+// commits are all in into counts as not worked.
+func branchWorked(wtDir, branch, into string) bool {
+	if ahead, _, err := AheadBehind(wtDir, into, "HEAD"); err == nil && ahead > 0 {
+		return true
+	}
+	subjects, err := ReflogSubjects(wtDir, branch)
+	if err != nil {
+		return false
+	}
+	for _, s := range subjects {
+		if strings.HasPrefix(s, "commit:") || strings.HasPrefix(s, "commit (amend):") || strings.HasPrefix(s, "cherry-pick:") {
+			return true
+		}
+	}
+	return false
+}
+
+// workspaceLastActive returns the latest commit time across the workspace's
+// worktrees, or its creation time if that is later.
+func workspaceLastActive(ws *WorkspaceInfo) time.Time {
+	var last time.Time
+	if ws.Metadata != nil {
+		last = ws.Metadata.CreatedAt
+	}
+	for _, rs := range ws.RepoStatuses {
+		t, err := CommitTime(filepath.Join(ws.Path, rs.RepoName), "HEAD")
+		if err == nil && t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
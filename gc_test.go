@@ -0,0 +1,98 @@
+package mangrove
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFindGCCandidates(t *testing.T) {
+	repo := newTestRepo(t)
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := Profile{Repos: []Repo{{Name: "api", Path: repo}}}
+	cfg.Profiles = map[string]Profile{"proj": profile}
+
+	for _, name := range []string{"fresh", "legacy", "synced", "merged", "squashed", "open", "dirty", "broken"} {
+		if err := CreateWorkspace(cfg, &profile, "proj", name, nil); err != nil {
+			t.Fatalf("CreateWorkspace(%s) unexpected error: %v", name, err)
+		}
+	}
+	dir := func(name string) string {
+		return filepath.Join(GetWorkspacePath(cfg, "proj", name), "api")
+	}
+
+	// merged: fast-forwarded into main
+	writeAndCommit(t, dir("merged"), "merged.txt", "m\n", "merged work")
+	runGit(t, repo, "merge", "-q", "--ff-only", "merged")
+
+	// squashed: the same change cherry-picked onto main
+	writeAndCommit(t, dir("squashed"), "picked.txt", "p\n", "picked work")
+	runGit(t, repo, "cherry-pick", "squashed")
+
+	// open: work that is not on main
+	writeAndCommit(t, dir("open"), "open.txt", "o\n", "open work")
+
+	// legacy: no commits and no base commit recorded, like a workspace from before .mgv.yaml
+	legacyPath := GetWorkspacePath(cfg, "proj", "legacy")
+	meta, err := LoadWorkspaceMetadata(legacyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta.Repos[0].BaseCommit = ""
+	if err := SaveWorkspaceMetadata(legacyPath, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	// synced: no commits of its own, only fast-forwarded to the new main
+	runGit(t, dir("synced"), "merge", "-q", "--ff-only", "main")
+
+	// broken: the worktree's status cannot be read
+	if err := os.WriteFile(filepath.Join(dir("broken"), ".git"), []byte("gitdir: /nonexistent\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// dirty: merged but with uncommitted changes
+	writeAndCommit(t, dir("dirty"), "dirty.txt", "d\n", "dirty work")
+	runGit(t, repo, "merge", "-q", "dirty")
+	if err := os.WriteFile(filepath.Join(dir("dirty"), "dirty.txt"), []byte("changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	candidates, err := FindGCCandidates(cfg, "", GCOptions{Merged: true})
+	if err != nil {
+		t.Fatalf("FindGCCandidates() unexpected error: %v", err)
+	}
+	var names []string
+	for _, c := range candidates {
+		names = append(names, c.Workspace)
+		if !slices.Equal(c.Reasons, []string{GCMerged}) {
+			t.Errorf("%s reasons = %v, want [merged]", c.Workspace, c.Reasons)
+		}
+	}
+	if want := []string{"merged", "squashed"}; !slices.Equal(names, want) {
+		t.Errorf("merged candidates = %v, want %v", names, want)
+	}
+
+	// Every clean workspace has been idle for at least a nanosecond
+	candidates, err = FindGCCandidates(cfg, "proj", GCOptions{IdleFor: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("FindGCCandidates() unexpected error: %v", err)
+	}
+	names = nil
+	for _, c := range candidates {
+		names = append(names, c.Workspace)
+	}
+	if want := []string{"fresh", "legacy", "merged", "open", "squashed", "synced"}; !slices.Equal(names, want) {
+		t.Errorf("idle candidates = %v, want %v", names, want)
+	}
+
+	candidates, err = FindGCCandidates(cfg, "proj", GCOptions{IdleFor: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("FindGCCandidates() unexpected error: %v", err)
+	}
+	if len(candidates) != 0 {
+		t.Errorf("FindGCCandidates() with 24h idle = %+v, want none", candidates)
+	}
+}
@@ -2,9 +2,12 @@ package mangrove
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // WorktreeAdd creates a new worktree with a new branch.
@@ -18,6 +21,28 @@ func WorktreeAdd(repoPath, worktreePath, branch, base string) error {
 	return nil
 }
 
+// WorktreeAddExisting creates a new worktree that checks out an existing local branch.
+// Equivalent to: git -C <repoPath> worktree add <worktreePath> <branch>
+func WorktreeAddExisting(repoPath, worktreePath, branch string) error {
+	cmd := exec.Command("git", "-C", repoPath, "worktree", "add", worktreePath, branch)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git worktree add failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// WorktreeAddTracking creates a new worktree with a new branch that tracks a remote branch.
+// Equivalent to: git -C <repoPath> worktree add --track -b <branch> <worktreePath> <remoteRef>
+func WorktreeAddTracking(repoPath, worktreePath, branch, remoteRef string) error {
+	cmd := exec.Command("git", "-C", repoPath, "worktree", "add", "--track", "-b", branch, worktreePath, remoteRef)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git worktree add failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
 // WorktreeRemove removes an existing worktree.
 // Equivalent to: git -C <repoPath> worktree remove <worktreePath>
 func WorktreeRemove(repoPath, worktreePath string, force bool) error {
@@ -33,13 +58,50 @@ func WorktreeRemove(repoPath, worktreePath string, force bool) error {
 	return nil
 }
 
+// WorktreeMove moves a worktree to a new location.
+// Equivalent to: git -C <repoPath> worktree move <worktreePath> <newPath>
+func WorktreeMove(repoPath, worktreePath, newPath string) error {
+	cmd := exec.Command("git", "-C", repoPath, "worktree", "move", worktreePath, newPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git worktree move failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// WorktreePrune removes the registrations of worktrees whose directories no longer exist.
+// Equivalent to: git -C <repoPath> worktree prune
+func WorktreePrune(repoPath string) error {
+	cmd := exec.Command("git", "-C", repoPath, "worktree", "prune")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git worktree prune failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// WorktreeRepair re-links a worktree with its repository after either was moved.
+// Equivalent to: git -C <repoPath> worktree repair <worktreePath>
+func WorktreeRepair(repoPath, worktreePath string) error {
+	cmd := exec.Command("git", "-C", repoPath, "worktree", "repair", worktreePath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git worktree repair failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
 // WorktreeEntry represents a single worktree from porcelain output.
 type WorktreeEntry struct {
-	Worktree string
-	HEAD     string
-	Branch   string
-	Bare     bool
-	Detached bool
+	Worktree       string
+	HEAD           string
+	Branch         string
+	Bare           bool
+	Detached       bool
+	Locked         bool
+	LockReason     string
+	Prunable       bool
+	PrunableReason string
 }
 
 // WorktreeList lists worktrees for a repository in porcelain format.
@@ -50,11 +112,15 @@ func WorktreeList(repoPath string) ([]WorktreeEntry, error) {
 	if err != nil {
 		return nil, fmt.Errorf("git worktree list failed: %w", err)
 	}
+	return parseWorktreeList(string(output)), nil
+}
 
+// parseWorktreeList parses the output of git worktree list --porcelain.
+func parseWorktreeList(output string) []WorktreeEntry {
 	var entries []WorktreeEntry
 	var current WorktreeEntry
 
-	for _, line := range strings.Split(string(output), "\n") {
+	for _, line := range strings.Split(output, "\n") {
 		line = strings.TrimSpace(line)
 		switch {
 		case strings.HasPrefix(line, "worktree "):
@@ -70,6 +136,12 @@ func WorktreeList(repoPath string) ([]WorktreeEntry, error) {
 			current.Bare = true
 		case line == "detached":
 			current.Detached = true
+		case line == "locked" || strings.HasPrefix(line, "locked "):
+			current.Locked = true
+			current.LockReason = strings.TrimSpace(strings.TrimPrefix(line, "locked"))
+		case line == "prunable" || strings.HasPrefix(line, "prunable "):
+			current.Prunable = true
+			current.PrunableReason = strings.TrimSpace(strings.TrimPrefix(line, "prunable"))
 		case line == "":
 			if current.Worktree != "" {
 				entries = append(entries, current)
@@ -81,7 +153,7 @@ func WorktreeList(repoPath string) ([]WorktreeEntry, error) {
 		entries = append(entries, current)
 	}
 
-	return entries, nil
+	return entries
 }
 
 // BranchList returns the list of local branch names for a repository.
@@ -129,6 +201,85 @@ func StatusChangedCount(path string) (int, error) {
 	return len(strings.Split(status, "\n")), nil
 }
 
+// ChangedFile is a changed path in a worktree with its two-letter porcelain status code.
+type ChangedFile struct {
+	Status string
+	Path   string
+}
+
+// ChangedFiles lists every changed and untracked file in a worktree.
+// Renames are reported as a deletion and an addition.
+// Equivalent to: git -C <path> status --porcelain -z -uall --no-renames
+func ChangedFiles(path string) ([]ChangedFile, error) {
+	cmd := exec.Command("git", "-C", path, "status", "--porcelain", "-z", "-uall", "--no-renames")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git status failed: %w", err)
+	}
+
+	var files []ChangedFile
+	for _, entry := range strings.Split(string(output), "\x00") {
+		if len(entry) < 4 {
+			continue
+		}
+		files = append(files, ChangedFile{Status: entry[:2], Path: entry[3:]})
+	}
+	return files, nil
+}
+
+// StageAll stages every change in a worktree, or only the given paths.
+// Equivalent to: git -C <path> add -A [-- <paths>...]
+func StageAll(path string, paths ...string) error {
+	args := []string{"-C", path, "add", "-A"}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	cmd := exec.Command("git", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git add failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// Commit records the staged changes, or only the given paths, with a message.
+// Commit hooks run as usual.
+// Equivalent to: git -C <path> commit -m <message> [-- <paths>...]
+func Commit(path, message string, paths ...string) error {
+	args := []string{"-C", path, "commit", "-m", message}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	cmd := exec.Command("git", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git commit failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// LogEntry is a commit listed by CommitLog.
+type LogEntry struct {
+	Hash    string `json:"hash"    yaml:"hash"`
+	Subject string `json:"subject" yaml:"subject"`
+}
+
+// CommitLog returns the commits in revRange, oldest first.
+// Equivalent to: git -C <path> log --reverse --format=%H%x00%s <revRange>
+func CommitLog(path, revRange string) ([]LogEntry, error) {
+	cmd := exec.Command("git", "-C", path, "log", "--reverse", "--format=%H%x00%s", revRange, "--")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %w", err)
+	}
+	var entries []LogEntry
+	for _, line := range parseLines(string(output)) {
+		hash, subject, _ := strings.Cut(line, "\x00")
+		entries = append(entries, LogEntry{Hash: hash, Subject: subject})
+	}
+	return entries, nil
+}
+
 // AheadBehind returns the number of commits ahead and behind between branch and base.
 // Equivalent to: git -C <repoPath> rev-list --count --left-right <base>...<branch>
 func AheadBehind(repoPath, base, branch string) (ahead int, behind int, err error) {
@@ -155,6 +306,52 @@ func AheadBehind(repoPath, base, branch string) (ahead int, behind int, err erro
 	return ahead, behind, nil
 }
 
+// UnmergedCount returns the number of commits on branch whose changes are not in base.
+// Unlike AheadBehind, commits that were cherry-picked or rebased onto base count as merged.
+// Equivalent to: git -C <repoPath> cherry <base> <branch>
+func UnmergedCount(repoPath, base, branch string) (int, error) {
+	cmd := exec.Command("git", "-C", repoPath, "cherry", base, branch)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("git cherry failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+
+	count := 0
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "+ ") {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ReflogSubjects returns the reflog messages of a ref, newest first, such as
+// "commit: <subject>" or "branch: Created from main". A ref without a reflog has none.
+// Equivalent to: git -C <path> log -g --format=%gs <ref> --
+func ReflogSubjects(path, ref string) ([]string, error) {
+	cmd := exec.Command("git", "-C", path, "log", "-g", "--format=%gs", ref, "--")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log -g failed: %w", err)
+	}
+	return parseLines(string(output)), nil
+}
+
+// CommitTime returns the committer date of a revision.
+// Equivalent to: git -C <path> log -1 --format=%ct <rev>
+func CommitTime(path, rev string) (time.Time, error) {
+	cmd := exec.Command("git", "-C", path, "log", "-1", "--format=%ct", rev)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("git log failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	sec, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse commit time: %w", err)
+	}
+	return time.Unix(sec, 0), nil
+}
+
 // BranchDelete deletes a local branch.
 // Equivalent to: git -C <repoPath> branch -d <branch>
 func BranchDelete(repoPath, branch string, force bool) error {
@@ -170,6 +367,94 @@ func BranchDelete(repoPath, branch string, force bool) error {
 	return nil
 }
 
+// BranchRename renames a local branch. Worktrees that have it checked out follow the rename.
+// Equivalent to: git -C <repoPath> branch -m <oldBranch> <newBranch>
+func BranchRename(repoPath, oldBranch, newBranch string) error {
+	cmd := exec.Command("git", "-C", repoPath, "branch", "-m", oldBranch, newBranch)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git branch rename failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// BranchCreate creates a branch pointing at a commit without checking it out.
+// Equivalent to: git -C <repoPath> branch <branch> <commit>
+func BranchCreate(repoPath, branch, commit string) error {
+	cmd := exec.Command("git", "-C", repoPath, "branch", branch, commit)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git branch failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// BundleCreate writes the commits of branch that are not in base to a bundle file.
+// Equivalent to: git -C <repoPath> bundle create <file> <branch> ^<base>
+func BundleCreate(repoPath, file, branch, base string) error {
+	cmd := exec.Command("git", "-C", repoPath, "bundle", "create", file, branch, "^"+base)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git bundle create failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// BundleFetch recreates a branch from a bundle file.
+// Equivalent to: git -C <repoPath> fetch <file> refs/heads/<branch>:refs/heads/<branch>
+func BundleFetch(repoPath, file, branch string) error {
+	ref := "refs/heads/" + branch
+	cmd := exec.Command("git", "-C", repoPath, "fetch", file, ref+":"+ref)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git fetch from bundle failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// DiffUncommitted returns every uncommitted change in a worktree, including untracked
+// files, as a binary patch against HEAD. A temporary index is used so the worktree's
+// own index is left untouched.
+// Equivalent to: git add -A && git diff --cached --binary HEAD (with GIT_INDEX_FILE set)
+func DiffUncommitted(path string) ([]byte, error) {
+	tmp, err := os.CreateTemp("", "mgv-index-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	tmp.Close()
+	// git refuses to read an empty file as an index
+	os.Remove(tmp.Name())
+	defer os.Remove(tmp.Name())
+
+	env := append(os.Environ(), "GIT_INDEX_FILE="+tmp.Name())
+	for _, args := range [][]string{{"read-tree", "HEAD"}, {"add", "-A"}} {
+		cmd := exec.Command("git", append([]string{"-C", path}, args...)...)
+		cmd.Env = env
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("git %s failed: %s: %w", args[0], strings.TrimSpace(string(output)), err)
+		}
+	}
+
+	cmd := exec.Command("git", "-C", path, "diff", "--cached", "--binary", "HEAD")
+	cmd.Env = env
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff failed: %w", err)
+	}
+	return output, nil
+}
+
+// ApplyPatch applies a patch file to the worktree without staging it.
+// Equivalent to: git -C <path> apply --binary <file>
+func ApplyPatch(path, file string) error {
+	cmd := exec.Command("git", "-C", path, "apply", "--binary", file)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git apply failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
 // FetchAll fetches from all remotes.
 // Equivalent to: git -C <repoPath> fetch --all
 func FetchAll(repoPath string) error {
@@ -181,6 +466,87 @@ func FetchAll(repoPath string) error {
 	return nil
 }
 
+// Push pushes a branch to a remote and sets it as the branch's upstream.
+// Equivalent to: git -C <path> push --set-upstream [--force-with-lease] <remote> <branch>
+func Push(path, remote, branch string, forceWithLease bool) error {
+	args := []string{"-C", path, "push", "--set-upstream"}
+	if forceWithLease {
+		args = append(args, "--force-with-lease")
+	}
+	args = append(args, remote, branch)
+	cmd := exec.Command("git", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git push failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// Upstream returns the upstream of a branch, such as origin/feature, or an error
+// if none is configured.
+// Equivalent to: git -C <path> rev-parse --abbrev-ref <branch>@{upstream}
+func Upstream(path, branch string) (string, error) {
+	cmd := exec.Command("git", "-C", path, "rev-parse", "--abbrev-ref", branch+"@{upstream}")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// BranchRemote returns the remote configured for a branch, or "" if there is none.
+// Equivalent to: git -C <path> config --get branch.<branch>.remote
+func BranchRemote(path, branch string) string {
+	cmd := exec.Command("git", "-C", path, "config", "--get", "branch."+branch+".remote")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// BranchSetWorktree records the workspace worktree a branch was created for, so the
+// branch can still be found after the worktree is gone. git branch -m and -d move or
+// remove the setting along with the branch.
+// Equivalent to: git -C <repoPath> config branch.<branch>.mgv-worktree <worktreePath>
+func BranchSetWorktree(repoPath, branch, worktreePath string) error {
+	cmd := exec.Command("git", "-C", repoPath, "config", "branch."+branch+".mgv-worktree", worktreePath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git config failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// WorktreeBranches returns the branches recorded with BranchSetWorktree, mapped to
+// the worktree path each was created for.
+// Equivalent to: git -C <repoPath> config --get-regexp ^branch\..*\.mgv-worktree$
+func WorktreeBranches(repoPath string) (map[string]string, error) {
+	cmd := exec.Command("git", "-C", repoPath, "config", "--get-regexp", `^branch\..*\.mgv-worktree$`)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			// Exit code 1 means no branch has the setting
+			if exitErr.ExitCode() == 1 {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("git config failed: %s: %w", strings.TrimSpace(string(exitErr.Stderr)), err)
+		}
+		return nil, fmt.Errorf("git config failed: %w", err)
+	}
+
+	branches := make(map[string]string)
+	for _, line := range parseLines(string(output)) {
+		key, path, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		branch := strings.TrimSuffix(strings.TrimPrefix(key, "branch."), ".mgv-worktree")
+		branches[branch] = path
+	}
+	return branches, nil
+}
+
 // CurrentBranch returns the current branch name of a worktree or repo.
 func CurrentBranch(path string) (string, error) {
 	cmd := exec.Command("git", "-C", path, "rev-parse", "--abbrev-ref", "HEAD")
@@ -191,6 +557,162 @@ func CurrentBranch(path string) (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// RevParse resolves a revision to its full commit hash.
+// Equivalent to: git -C <path> rev-parse --verify <rev>^{commit}
+func RevParse(path, rev string) (string, error) {
+	cmd := exec.Command("git", "-C", path, "rev-parse", "--verify", rev+"^{commit}")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// MergeBase returns the best common ancestor of two commits.
+// Equivalent to: git -C <path> merge-base <a> <b>
+func MergeBase(path, a, b string) (string, error) {
+	cmd := exec.Command("git", "-C", path, "merge-base", a, b)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git merge-base failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// DiffNameStatus lists the files changed between two commits as "<status> <path>" lines.
+// Renames are reported as a deletion and an addition.
+// Equivalent to: git -C <path> diff --name-status --no-renames <from> <to>
+func DiffNameStatus(path, from, to string) ([]string, error) {
+	cmd := exec.Command("git", "-C", path, "diff", "--name-status", "--no-renames", from, to, "--")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff failed: %w", err)
+	}
+	var files []string
+	for _, line := range parseLines(string(output)) {
+		status, file, _ := strings.Cut(line, "\t")
+		files = append(files, status+" "+file)
+	}
+	return files, nil
+}
+
+// MergeTree merges branch2 into branch1 in memory, without touching any ref, index or
+// working tree, and returns the files that would conflict. If mergeBase is set it is
+// used instead of the merge base of the two branches (this needs git 2.40 or later).
+// Equivalent to: git -C <path> merge-tree --write-tree --name-only --no-messages [--merge-base=<mergeBase>] <branch1> <branch2>
+func MergeTree(path, mergeBase, branch1, branch2 string) ([]string, error) {
+	args := []string{"-C", path, "merge-tree", "--write-tree", "--name-only", "--no-messages"}
+	if mergeBase != "" {
+		args = append(args, "--merge-base="+mergeBase)
+	}
+	args = append(args, branch1, branch2)
+	cmd := exec.Command("git", args...)
+	output, err := cmd.Output()
+	if err == nil {
+		return nil, nil
+	}
+
+	// Exit code 1 means the merge has conflicts: the tree is followed by the conflicted files
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 1 {
+		if ok {
+			return nil, fmt.Errorf("git merge-tree failed: %s: %w", strings.TrimSpace(string(exitErr.Stderr)), err)
+		}
+		return nil, fmt.Errorf("git merge-tree failed: %w", err)
+	}
+	lines := parseLines(string(output))
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("git merge-tree failed: unexpected empty output")
+	}
+	return slices.Compact(lines[1:]), nil
+}
+
+// Diff returns the diff of a worktree against its index, or with staged set, of the
+// index against HEAD. If rev is set, the working tree is compared against rev instead.
+// prefix is inserted after a/ and b/ in every path.
+// Equivalent to: git -C <path> diff [--cached] --color=<always|never> --src-prefix=a/<prefix> --dst-prefix=b/<prefix> [<rev>]
+func Diff(path, rev string, staged bool, prefix string, color bool) ([]byte, error) {
+	args := []string{"-C", path, "diff", colorFlag(color), "--src-prefix=a/" + prefix, "--dst-prefix=b/" + prefix}
+	if staged {
+		args = append(args, "--cached")
+	}
+	if rev != "" {
+		args = append(args, rev)
+	}
+	cmd := exec.Command("git", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff failed: %w", err)
+	}
+	return output, nil
+}
+
+// Log returns the commits in revRange one per line, or with patch set, in full with
+// their diffs. prefix is inserted after a/ and b/ in every path of the diffs.
+// Equivalent to: git -C <path> log --color=<always|never> (--format=<oneline> | -p --src-prefix=a/<prefix> --dst-prefix=b/<prefix>) <revRange>
+func Log(path, revRange string, patch bool, prefix string, color bool) ([]byte, error) {
+	args := []string{"-C", path, "log", colorFlag(color)}
+	if patch {
+		args = append(args, "-p", "--src-prefix=a/"+prefix, "--dst-prefix=b/"+prefix)
+	} else {
+		args = append(args, "--format=%C(yellow)%h%C(reset) %s %C(dim)(%an, %ar)%C(reset)")
+	}
+	args = append(args, revRange, "--")
+	cmd := exec.Command("git", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %w", err)
+	}
+	return output, nil
+}
+
+// colorFlag returns the git --color option forcing color on or off.
+func colorFlag(color bool) string {
+	if color {
+		return "--color=always"
+	}
+	return "--color=never"
+}
+
+// FormatPatch writes one patch file per commit in revRange to outDir and returns
+// the file paths in order.
+// Equivalent to: git -C <path> format-patch -o <outDir> <revRange>
+func FormatPatch(path, revRange, outDir string) ([]string, error) {
+	cmd := exec.Command("git", "-C", path, "format-patch", "-o", outDir, revRange)
+	output, err := cmd.Output()
+	if err != nil {
+		// stderr is only used for the error message; stdout has the file names
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git format-patch failed: %s: %w", strings.TrimSpace(string(exitErr.Stderr)), err)
+		}
+		return nil, fmt.Errorf("git format-patch failed: %w", err)
+	}
+	return parseLines(string(output)), nil
+}
+
+// Am applies a series of patch files as commits, falling back to a three-way merge.
+// Equivalent to: git -C <path> am --3way <files>...
+func Am(path string, files []string) error {
+	args := append([]string{"-C", path, "am", "--3way"}, files...)
+	cmd := exec.Command("git", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git am failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// AmAbort aborts a git am in progress and restores the original branch.
+// Equivalent to: git -C <path> am --abort
+func AmAbort(path string) error {
+	cmd := exec.Command("git", "-C", path, "am", "--abort")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git am --abort failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
 // StashPush creates a stash entry with a message.
 // Equivalent to: git -C <path> stash push -m <message>
 func StashPush(path, message string) error {
@@ -202,6 +724,89 @@ func StashPush(path, message string) error {
 	return nil
 }
 
+// StashPushUntracked stashes uncommitted changes including untracked files with a message.
+// Equivalent to: git -C <path> stash push --include-untracked -m <message>
+func StashPushUntracked(path, message string) error {
+	cmd := exec.Command("git", "-C", path, "stash", "push", "--include-untracked", "-m", message)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git stash push failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// StashApply applies the latest stash entry without removing it.
+// Equivalent to: git -C <path> stash apply
+func StashApply(path string) error {
+	cmd := exec.Command("git", "-C", path, "stash", "apply")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git stash apply failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// StashCreate records the uncommitted changes of tracked files as a stash commit
+// without touching the working tree or the stash list. Returns "" if there are none.
+// Equivalent to: git -C <path> stash create
+func StashCreate(path string) (string, error) {
+	cmd := exec.Command("git", "-C", path, "stash", "create")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git stash create failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// StashApplyCommit applies a specific stash entry, identified by its commit, without removing it.
+// Equivalent to: git -C <path> stash apply <commit>
+func StashApplyCommit(path, commit string) error {
+	cmd := exec.Command("git", "-C", path, "stash", "apply", commit)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git stash apply failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// StashDropCommit removes the stash entry with the given commit. It is not an error
+// if the entry is already gone.
+// Equivalent to: git -C <path> stash drop stash@{<n>} (where stash@{<n>} is <commit>)
+func StashDropCommit(path, commit string) error {
+	cmd := exec.Command("git", "-C", path, "stash", "list", "--format=%H")
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("git stash list failed: %w", err)
+	}
+	for i, hash := range parseLines(string(output)) {
+		if hash != commit {
+			continue
+		}
+		cmd := exec.Command("git", "-C", path, "stash", "drop", fmt.Sprintf("stash@{%d}", i))
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("git stash drop failed: %s: %w", strings.TrimSpace(string(output)), err)
+		}
+		return nil
+	}
+	return nil
+}
+
+// StashUntrackedFiles lists the untracked files saved in a stash entry, if any.
+// Equivalent to: git -C <path> ls-tree -r --name-only <commit>^3
+func StashUntrackedFiles(path, commit string) ([]string, error) {
+	if err := exec.Command("git", "-C", path, "rev-parse", "--verify", "--quiet", commit+"^3").Run(); err != nil {
+		// The stash has no untracked files
+		return nil, nil
+	}
+	cmd := exec.Command("git", "-C", path, "ls-tree", "-r", "--name-only", commit+"^3")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-tree failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return parseLines(string(output)), nil
+}
+
 // StashPop applies and removes the latest stash entry.
 // Equivalent to: git -C <path> stash pop
 func StashPop(path string) error {
@@ -246,6 +851,166 @@ func Merge(path, branch string) error {
 	return nil
 }
 
+// MergeSquash stages the changes of the specified branch on top of the current branch
+// without committing them.
+// Equivalent to: git -C <path> merge --squash <branch>
+func MergeSquash(path, branch string) error {
+	cmd := exec.Command("git", "-C", path, "merge", "--squash", branch)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git merge --squash failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// CherryPick applies the given commits, in order, on top of the current branch.
+// Equivalent to: git -C <path> cherry-pick <commits>...
+func CherryPick(path string, commits []string) error {
+	args := append([]string{"-C", path, "cherry-pick"}, commits...)
+	cmd := exec.Command("git", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git cherry-pick failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// CherryPickContinue continues an in-progress cherry-pick without opening an editor.
+// Equivalent to: GIT_EDITOR=true git -C <path> cherry-pick --continue
+func CherryPickContinue(path string) error {
+	cmd := exec.Command("git", "-C", path, "cherry-pick", "--continue")
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git cherry-pick --continue failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// CherryPickAbort aborts an in-progress cherry-pick.
+// Equivalent to: git -C <path> cherry-pick --abort
+func CherryPickAbort(path string) error {
+	cmd := exec.Command("git", "-C", path, "cherry-pick", "--abort")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git cherry-pick --abort failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// Rebase rebases the current branch onto the specified upstream.
+// Equivalent to: git -C <path> rebase <upstream>
+func Rebase(path, upstream string) error {
+	cmd := exec.Command("git", "-C", path, "rebase", upstream)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git rebase failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// RebaseContinue continues an in-progress rebase without opening an editor.
+// Equivalent to: GIT_EDITOR=true git -C <path> rebase --continue
+func RebaseContinue(path string) error {
+	cmd := exec.Command("git", "-C", path, "rebase", "--continue")
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git rebase --continue failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// RebaseAbort aborts an in-progress rebase.
+// Equivalent to: git -C <path> rebase --abort
+func RebaseAbort(path string) error {
+	cmd := exec.Command("git", "-C", path, "rebase", "--abort")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git rebase --abort failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// MergeContinue concludes an in-progress merge without opening an editor.
+// Equivalent to: GIT_EDITOR=true git -C <path> merge --continue
+func MergeContinue(path string) error {
+	cmd := exec.Command("git", "-C", path, "merge", "--continue")
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git merge --continue failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// MergeAbort aborts an in-progress merge.
+// Equivalent to: git -C <path> merge --abort
+func MergeAbort(path string) error {
+	cmd := exec.Command("git", "-C", path, "merge", "--abort")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git merge --abort failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// ResetHard resets the current branch and working tree to the given commit.
+// Equivalent to: git -C <path> reset --hard <commit>
+func ResetHard(path, commit string) error {
+	cmd := exec.Command("git", "-C", path, "reset", "--hard", commit)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git reset --hard failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// UnmergedFiles returns the files with unresolved conflicts in a worktree.
+// Equivalent to: git -C <path> diff --name-only --diff-filter=U
+func UnmergedFiles(path string) ([]string, error) {
+	cmd := exec.Command("git", "-C", path, "diff", "--name-only", "--diff-filter=U")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff failed: %w", err)
+	}
+	return parseLines(string(output)), nil
+}
+
+// RefExists reports whether a ref (e.g., refs/remotes/origin/main) exists.
+// Equivalent to: git -C <path> show-ref --verify --quiet <ref>
+func RefExists(path, ref string) bool {
+	cmd := exec.Command("git", "-C", path, "show-ref", "--verify", "--quiet", ref)
+	return cmd.Run() == nil
+}
+
+// IsRebaseInProgress reports whether a rebase is in progress in the worktree.
+func IsRebaseInProgress(path string) bool {
+	return gitPathExists(path, "rebase-merge") || gitPathExists(path, "rebase-apply")
+}
+
+// IsMergeInProgress reports whether a merge is in progress in the worktree.
+func IsMergeInProgress(path string) bool {
+	return gitPathExists(path, "MERGE_HEAD")
+}
+
+// IsCherryPickInProgress reports whether a cherry-pick is in progress in the worktree.
+func IsCherryPickInProgress(path string) bool {
+	return gitPathExists(path, "CHERRY_PICK_HEAD") || gitPathExists(path, "sequencer")
+}
+
+// gitPathExists reports whether a file inside the worktree's git directory exists.
+// Equivalent to: test -e $(git -C <path> rev-parse --git-path <name>)
+func gitPathExists(path, name string) bool {
+	cmd := exec.Command("git", "-C", path, "rev-parse", "--path-format=absolute", "--git-path", name)
+	output, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(strings.TrimSpace(string(output)))
+	return err == nil
+}
+
 // parseLines splits output by newlines and returns non-empty trimmed lines.
 func parseLines(output string) []string {
 	var lines []string
@@ -1,6 +1,10 @@
 package mangrove
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -59,3 +63,75 @@ func TestParseLines(t *testing.T) {
 		})
 	}
 }
+
+func TestParseWorktreeList(t *testing.T) {
+	output := `worktree /repos/api
+HEAD 1111111111111111111111111111111111111111
+branch refs/heads/main
+
+worktree /ws/p/feature-x/api
+HEAD 2222222222222222222222222222222222222222
+branch refs/heads/feature-x
+locked
+
+worktree /ws/p/gone/api
+HEAD 3333333333333333333333333333333333333333
+detached
+locked moved to usb drive
+prunable gitdir file points to non-existent location
+
+`
+	got := parseWorktreeList(output)
+	want := []WorktreeEntry{
+		{Worktree: "/repos/api", HEAD: "1111111111111111111111111111111111111111", Branch: "refs/heads/main"},
+		{Worktree: "/ws/p/feature-x/api", HEAD: "2222222222222222222222222222222222222222", Branch: "refs/heads/feature-x", Locked: true},
+		{
+			Worktree:       "/ws/p/gone/api",
+			HEAD:           "3333333333333333333333333333333333333333",
+			Detached:       true,
+			Locked:         true,
+			LockReason:     "moved to usb drive",
+			Prunable:       true,
+			PrunableReason: "gitdir file points to non-existent location",
+		},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("parseWorktreeList() returned %d entries, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("parseWorktreeList()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// newTestRepo creates a git repository with a single commit on main and returns its path.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+
+	t.Setenv("GIT_AUTHOR_NAME", "mgv test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "mgv@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "mgv test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "mgv@example.com")
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatalf("failed to write README.md: %v", err)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "initial commit")
+	return dir
+}
+
+// runGit runs a git command in dir and fails the test on error.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %s: %v", args, output, err)
+	}
+	return strings.TrimSpace(string(output))
+}
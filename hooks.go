@@ -0,0 +1,223 @@
+package mangrove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Hook stages.
+const (
+	HookPreCreate  = "pre_create"
+	HookPostCreate = "post_create"
+	HookPreRemove  = "pre_remove"
+	HookPostRemove = "post_remove"
+	HookPostApply  = "post_apply"
+)
+
+// Hook failure policies.
+const (
+	// HookWarn prints a warning and keeps going. This is the default.
+	HookWarn = "warn"
+	// HookAbort stops the remaining hooks and fails the operation.
+	HookAbort = "abort"
+	// HookRollback stops the remaining hooks and undoes the operation where possible
+	// (post_create removes the new workspace). Other stages treat it as abort.
+	HookRollback = "rollback"
+)
+
+// HookRepo describes a repo that per-repo hooks can run in.
+type HookRepo struct {
+	Name   string
+	Branch string
+	Base   string
+	Dir    string
+}
+
+// HookContext describes where and for what a hook stage runs.
+type HookContext struct {
+	Stage         string
+	Profile       string
+	Workspace     string
+	WorkspacePath string
+	// Dir is the directory workspace-level hooks run in.
+	Dir   string
+	Repos []HookRepo
+}
+
+// HookError is returned by RunHooks when a hook with on_failure abort or rollback fails.
+type HookError struct {
+	Stage     string
+	Hook      Hook
+	OnFailure string
+	Err       error
+}
+
+func (e *HookError) Error() string {
+	target := "workspace"
+	if e.Hook.Repo != "" {
+		target = e.Hook.Repo
+	}
+	return fmt.Sprintf("%s hook failed for %s (%s): %v", e.Stage, target, e.Hook.Run, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// Rollback reports whether the failed hook asked for the operation to be rolled back.
+func (e *HookError) Rollback() bool {
+	return e.OnFailure == HookRollback
+}
+
+// ForStage returns the hooks configured for a stage.
+func (h Hooks) ForStage(stage string) []Hook {
+	switch stage {
+	case HookPreCreate:
+		return h.PreCreate
+	case HookPostCreate:
+		return h.PostCreate
+	case HookPreRemove:
+		return h.PreRemove
+	case HookPostRemove:
+		return h.PostRemove
+	case HookPostApply:
+		return h.PostApply
+	default:
+		return nil
+	}
+}
+
+// GetOnFailure returns the failure policy of a hook, defaulting to warn.
+func (h *Hook) GetOnFailure() string {
+	if h.OnFailure == "" {
+		return HookWarn
+	}
+	return h.OnFailure
+}
+
+// RunHooks runs the hooks of a stage in order and returns a record of each hook that ran.
+// Hooks whose repo is not part of ctx.Repos are skipped with a warning.
+// It returns a *HookError as soon as a hook with on_failure abort or rollback fails.
+func RunHooks(hooks []Hook, ctx HookContext) ([]HookRecord, error) {
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+
+	PrintSuccess("Running %s hooks...", ctx.Stage)
+
+	var records []HookRecord
+	for _, hook := range hooks {
+		switch hook.GetOnFailure() {
+		case HookWarn, HookAbort, HookRollback:
+		default:
+			return records, fmt.Errorf("%s hook %q: unknown on_failure %q (use warn, abort or rollback)", ctx.Stage, hook.Run, hook.OnFailure)
+		}
+
+		env := []string{
+			"MGV_HOOK_STAGE=" + ctx.Stage,
+			"MGV_PROFILE=" + ctx.Profile,
+			"MGV_WORKSPACE=" + ctx.Workspace,
+			"MGV_WORKSPACE_PATH=" + ctx.WorkspacePath,
+		}
+
+		dir := ctx.Dir
+		if hook.Repo == "" {
+			names := make([]string, len(ctx.Repos))
+			for i, r := range ctx.Repos {
+				names[i] = r.Name
+			}
+			env = append(env, "MGV_REPOS="+strings.Join(names, ","))
+		} else {
+			repo, ok := findHookRepo(ctx.Repos, hook.Repo)
+			if !ok {
+				PrintWarning("Skipping %s hook for %s: repo not in workspace", ctx.Stage, hook.Repo)
+				continue
+			}
+			if _, err := os.Stat(repo.Dir); os.IsNotExist(err) {
+				PrintWarning("Skipping %s hook for %s: directory not found", ctx.Stage, hook.Repo)
+				continue
+			}
+			dir = repo.Dir
+			env = append(env,
+				"MGV_REPO="+repo.Name,
+				"MGV_BRANCH="+repo.Branch,
+				"MGV_BASE="+repo.Base,
+			)
+		}
+
+		record := HookRecord{Stage: ctx.Stage, Repo: hook.Repo, Run: hook.Run, Success: true}
+		err := runHook(hook, dir, env)
+		if err != nil {
+			record.Success = false
+		}
+		records = append(records, record)
+
+		if err == nil {
+			continue
+		}
+		if hook.GetOnFailure() == HookWarn {
+			target := hook.Repo
+			if target == "" {
+				target = "workspace"
+			}
+			PrintWarning("Hook failed for %s (%s): %v", target, hook.Run, err)
+			continue
+		}
+		return records, &HookError{Stage: ctx.Stage, Hook: hook, OnFailure: hook.GetOnFailure(), Err: err}
+	}
+
+	return records, nil
+}
+
+// runHook runs a single hook command with sh -c, honoring its timeout.
+func runHook(hook Hook, dir string, env []string) error {
+	if hook.Timeout == "" {
+		cmd := exec.Command("sh", "-c", hook.Run)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), env...)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+
+	timeout, err := time.ParseDuration(hook.Timeout)
+	if err != nil {
+		return fmt.Errorf("invalid timeout %q: %w", hook.Timeout, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook.Run)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	// Run the hook in its own process group so a timeout kills its children too
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", hook.Timeout)
+	}
+	return err
+}
+
+// findHookRepo returns the repo with the given name.
+func findHookRepo(repos []HookRepo, name string) (HookRepo, bool) {
+	for _, r := range repos {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return HookRepo{}, false
+}
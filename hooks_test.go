@@ -0,0 +1,138 @@
+package mangrove
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunHooksEnvAndDirs(t *testing.T) {
+	wsPath := t.TempDir()
+	repoDir := filepath.Join(wsPath, "api")
+	if err := os.MkdirAll(repoDir, 0o755); err != nil {
+		t.Fatalf("failed to create repo dir: %v", err)
+	}
+
+	hooks := []Hook{
+		{Repo: "api", Run: `echo "$MGV_HOOK_STAGE $MGV_PROFILE $MGV_WORKSPACE $MGV_REPO $MGV_BRANCH $MGV_BASE $MGV_WORKSPACE_PATH" > repo.txt`},
+		{Run: `echo "$MGV_REPOS" > workspace.txt`},
+	}
+	ctx := HookContext{
+		Stage:         HookPostCreate,
+		Profile:       "proj",
+		Workspace:     "login",
+		WorkspacePath: wsPath,
+		Dir:           wsPath,
+		Repos:         []HookRepo{{Name: "api", Branch: "feature/login", Base: "develop", Dir: repoDir}},
+	}
+
+	records, err := RunHooks(hooks, ctx)
+	if err != nil {
+		t.Fatalf("RunHooks() unexpected error: %v", err)
+	}
+	if len(records) != 2 || !records[0].Success || !records[1].Success {
+		t.Errorf("RunHooks() records = %+v, want 2 successful records", records)
+	}
+
+	got, _ := os.ReadFile(filepath.Join(repoDir, "repo.txt"))
+	want := "post_create proj login api feature/login develop " + wsPath
+	if strings.TrimSpace(string(got)) != want {
+		t.Errorf("repo hook env = %q, want %q", strings.TrimSpace(string(got)), want)
+	}
+
+	got, _ = os.ReadFile(filepath.Join(wsPath, "workspace.txt"))
+	if strings.TrimSpace(string(got)) != "api" {
+		t.Errorf("workspace hook MGV_REPOS = %q, want %q", strings.TrimSpace(string(got)), "api")
+	}
+}
+
+func TestRunHooksFailurePolicies(t *testing.T) {
+	tests := []struct {
+		name         string
+		hook         Hook
+		wantErr      bool
+		wantRollback bool
+		wantRecords  int
+	}{
+		{name: "warn continues", hook: Hook{Run: "exit 1"}, wantRecords: 2},
+		{name: "explicit warn continues", hook: Hook{Run: "exit 1", OnFailure: HookWarn}, wantRecords: 2},
+		{name: "abort stops", hook: Hook{Run: "exit 1", OnFailure: HookAbort}, wantErr: true, wantRecords: 1},
+		{name: "rollback stops", hook: Hook{Run: "exit 1", OnFailure: HookRollback}, wantErr: true, wantRollback: true, wantRecords: 1},
+		{name: "timeout fails", hook: Hook{Run: "sleep 5", Timeout: "100ms", OnFailure: HookAbort}, wantErr: true, wantRecords: 1},
+		{name: "invalid timeout fails", hook: Hook{Run: "true", Timeout: "soon", OnFailure: HookAbort}, wantErr: true, wantRecords: 1},
+		{name: "unknown policy", hook: Hook{Run: "true", OnFailure: "ignore"}, wantErr: true, wantRecords: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			hooks := []Hook{tt.hook, {Run: "true"}}
+			records, err := RunHooks(hooks, HookContext{Stage: HookPostCreate, Dir: dir})
+
+			if len(records) != tt.wantRecords {
+				t.Errorf("RunHooks() ran %d hooks, want %d", len(records), tt.wantRecords)
+			}
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("RunHooks() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("RunHooks() expected error")
+			}
+			var hookErr *HookError
+			if errors.As(err, &hookErr) && hookErr.Rollback() != tt.wantRollback {
+				t.Errorf("HookError.Rollback() = %v, want %v", hookErr.Rollback(), tt.wantRollback)
+			}
+		})
+	}
+}
+
+func TestRunHooksSkipsMissingRepo(t *testing.T) {
+	records, err := RunHooks([]Hook{{Repo: "web", Run: "exit 1", OnFailure: HookAbort}}, HookContext{Stage: HookPostCreate})
+	if err != nil {
+		t.Fatalf("RunHooks() unexpected error: %v", err)
+	}
+	if len(records) != 0 {
+		t.Errorf("RunHooks() should skip hooks for repos outside the workspace, got %+v", records)
+	}
+}
+
+func TestCreateWorkspaceHookRollback(t *testing.T) {
+	repoPath := newTestRepo(t)
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{
+		Repos: []Repo{{Name: "api", Path: repoPath}},
+		Hooks: Hooks{PostCreate: []Hook{{Repo: "api", Run: "exit 3", OnFailure: HookRollback}}},
+	}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "x", nil); err == nil {
+		t.Fatal("CreateWorkspace() expected error from rollback hook")
+	}
+
+	if _, err := os.Stat(GetWorkspacePath(cfg, "proj", "x")); !os.IsNotExist(err) {
+		t.Error("workspace should be removed after a rollback hook fails")
+	}
+	if RefExists(repoPath, "refs/heads/x") {
+		t.Error("branch created for the workspace should be deleted on rollback")
+	}
+}
+
+func TestRemoveWorkspacePreRemoveAbort(t *testing.T) {
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: []Repo{{Name: "api", Path: newTestRepo(t)}}}
+	if err := CreateWorkspace(cfg, profile, "proj", "x", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+
+	profile.Hooks.PreRemove = []Hook{{Run: "exit 1", OnFailure: HookAbort}}
+	if err := RemoveWorkspace(cfg, profile, "proj", "x", false, false); err == nil {
+		t.Fatal("RemoveWorkspace() expected error from aborting pre_remove hook")
+	}
+	if _, err := os.Stat(filepath.Join(GetWorkspacePath(cfg, "proj", "x"), "api")); err != nil {
+		t.Errorf("worktree should be kept when pre_remove aborts: %v", err)
+	}
+}
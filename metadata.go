@@ -0,0 +1,116 @@
+package mangrove
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MetadataFileName is the name of the metadata file written into each workspace root.
+const MetadataFileName = ".mgv.yaml"
+
+// Version is the mgv version recorded in workspace metadata.
+// It is set by the command package at startup.
+var Version = "dev"
+
+// RepoMetadata records how a single repo worktree was created.
+// Path is only set for repos added from outside the profile.
+type RepoMetadata struct {
+	Name       string `yaml:"name"           json:"name"`
+	Branch     string `yaml:"branch"         json:"branch"`
+	Base       string `yaml:"base"           json:"base"`
+	BaseCommit string `yaml:"base_commit"    json:"base_commit"`
+	Path       string `yaml:"path,omitempty" json:"path,omitempty"`
+}
+
+// HookRecord records a hook that ran for a workspace.
+type HookRecord struct {
+	Stage   string `yaml:"stage"   json:"stage"`
+	Repo    string `yaml:"repo"    json:"repo"`
+	Run     string `yaml:"run"     json:"run"`
+	Success bool   `yaml:"success" json:"success"`
+}
+
+// WorkspaceMetadata is the persistent state of a workspace, stored in .mgv.yaml.
+// Parent is set for workspaces forked from another workspace of the same profile.
+type WorkspaceMetadata struct {
+	Profile    string         `yaml:"profile"          json:"profile"`
+	Name       string         `yaml:"name"             json:"name"`
+	CreatedAt  time.Time      `yaml:"created_at"       json:"created_at"`
+	MgvVersion string         `yaml:"mgv_version"      json:"mgv_version"`
+	Parent     string         `yaml:"parent,omitempty" json:"parent,omitempty"`
+	Repos      []RepoMetadata `yaml:"repos"            json:"repos"`
+	Hooks      []HookRecord   `yaml:"hooks,omitempty"  json:"hooks,omitempty"`
+}
+
+// MetadataPath returns the path of the metadata file for a workspace.
+func MetadataPath(wsPath string) string {
+	return filepath.Join(wsPath, MetadataFileName)
+}
+
+// LoadWorkspaceMetadata reads the metadata file of a workspace.
+// Returns nil without error if the workspace has no metadata file
+// (e.g., it was created by an older mgv).
+func LoadWorkspaceMetadata(wsPath string) (*WorkspaceMetadata, error) {
+	data, err := os.ReadFile(MetadataPath(wsPath))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read workspace metadata: %w", err)
+	}
+
+	var meta WorkspaceMetadata
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse workspace metadata: %w", err)
+	}
+	return &meta, nil
+}
+
+// SaveWorkspaceMetadata writes the metadata file of a workspace.
+func SaveWorkspaceMetadata(wsPath string, meta *WorkspaceMetadata) error {
+	data, err := yaml.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal workspace metadata: %w", err)
+	}
+	if err := os.WriteFile(MetadataPath(wsPath), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write workspace metadata: %w", err)
+	}
+	return nil
+}
+
+// FindRepo returns the metadata for the named repo, or nil if it is not recorded.
+// It is safe to call on a nil receiver.
+func (m *WorkspaceMetadata) FindRepo(name string) *RepoMetadata {
+	if m == nil {
+		return nil
+	}
+	for i := range m.Repos {
+		if m.Repos[i].Name == name {
+			return &m.Repos[i]
+		}
+	}
+	return nil
+}
+
+// RepoBase returns the base branch the repo's worktree was created from,
+// falling back to the repo's default base when no metadata is recorded.
+// It is safe to call on a nil receiver.
+func (m *WorkspaceMetadata) RepoBase(repo *Repo) string {
+	if rm := m.FindRepo(repo.Name); rm != nil && rm.Base != "" {
+		return rm.Base
+	}
+	return repo.GetDefaultBase()
+}
+
+// RemoveRepo drops the named repo from the metadata.
+func (m *WorkspaceMetadata) RemoveRepo(name string) {
+	m.Repos = slices.DeleteFunc(m.Repos, func(rm RepoMetadata) bool {
+		return rm.Name == name
+	})
+}
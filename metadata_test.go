@@ -0,0 +1,105 @@
+package mangrove
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWorkspaceMetadataRoundTrip(t *testing.T) {
+	wsPath := t.TempDir()
+	meta := &WorkspaceMetadata{
+		Profile:    "proj",
+		Name:       "feature-x",
+		CreatedAt:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		MgvVersion: "v1.2.3",
+		Repos: []RepoMetadata{
+			{Name: "api", Branch: "feature-x", Base: "develop", BaseCommit: "abc123"},
+		},
+		Hooks: []HookRecord{
+			{Stage: "post_create", Repo: "api", Run: "make deps", Success: true},
+		},
+	}
+
+	if err := SaveWorkspaceMetadata(wsPath, meta); err != nil {
+		t.Fatalf("SaveWorkspaceMetadata() unexpected error: %v", err)
+	}
+
+	got, err := LoadWorkspaceMetadata(wsPath)
+	if err != nil {
+		t.Fatalf("LoadWorkspaceMetadata() unexpected error: %v", err)
+	}
+	if got.Profile != meta.Profile || got.Name != meta.Name || got.MgvVersion != meta.MgvVersion {
+		t.Errorf("LoadWorkspaceMetadata() = %+v, want %+v", got, meta)
+	}
+	if !got.CreatedAt.Equal(meta.CreatedAt) {
+		t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, meta.CreatedAt)
+	}
+	if len(got.Repos) != 1 || got.Repos[0] != meta.Repos[0] {
+		t.Errorf("Repos = %+v, want %+v", got.Repos, meta.Repos)
+	}
+	if len(got.Hooks) != 1 || got.Hooks[0] != meta.Hooks[0] {
+		t.Errorf("Hooks = %+v, want %+v", got.Hooks, meta.Hooks)
+	}
+}
+
+func TestLoadWorkspaceMetadataMissing(t *testing.T) {
+	meta, err := LoadWorkspaceMetadata(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadWorkspaceMetadata() unexpected error: %v", err)
+	}
+	if meta != nil {
+		t.Errorf("LoadWorkspaceMetadata() = %+v, want nil", meta)
+	}
+}
+
+func TestLoadWorkspaceMetadataInvalid(t *testing.T) {
+	wsPath := t.TempDir()
+	if err := os.WriteFile(MetadataPath(wsPath), []byte("repos: [unterminated"), 0o644); err != nil {
+		t.Fatalf("failed to write metadata: %v", err)
+	}
+	if _, err := LoadWorkspaceMetadata(wsPath); err == nil {
+		t.Error("LoadWorkspaceMetadata() expected error for invalid YAML")
+	}
+}
+
+func TestWorkspaceMetadataRepoBase(t *testing.T) {
+	meta := &WorkspaceMetadata{
+		Repos: []RepoMetadata{{Name: "api", Base: "release/1.0"}},
+	}
+
+	tests := []struct {
+		name string
+		meta *WorkspaceMetadata
+		repo Repo
+		want string
+	}{
+		{
+			name: "recorded base wins over default",
+			meta: meta,
+			repo: Repo{Name: "api", DefaultBase: "develop"},
+			want: "release/1.0",
+		},
+		{
+			name: "unrecorded repo uses default base",
+			meta: meta,
+			repo: Repo{Name: "web", DefaultBase: "develop"},
+			want: "develop",
+		},
+		{
+			name: "nil metadata uses default base",
+			meta: nil,
+			repo: Repo{Name: "api"},
+			want: "main",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.meta.RepoBase(&tt.repo)
+			if got != tt.want {
+				t.Errorf("RepoBase(%q) = %q, want %q", tt.repo.Name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package mangrove
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats accepted by the global --output flag.
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+// ProfileInfo is the machine-readable form of a profile, as emitted by `mgv profile show`.
+type ProfileInfo struct {
+	Name    string `json:"name"    yaml:"name"`
+	Default bool   `json:"default" yaml:"default"`
+	// Source is the shared config file the profile comes from, if any.
+	Source  string `json:"source,omitempty" yaml:"source,omitempty"`
+	Profile `yaml:",inline"`
+}
+
+// NewProfileInfo builds a ProfileInfo, normalizing nil lists to empty ones
+// so that JSON output always contains arrays.
+func NewProfileInfo(name string, profile Profile, isDefault bool) ProfileInfo {
+	if profile.Repos == nil {
+		profile.Repos = []Repo{}
+	}
+	for _, hooks := range []*[]Hook{
+		&profile.Hooks.PreCreate,
+		&profile.Hooks.PostCreate,
+		&profile.Hooks.PreRemove,
+		&profile.Hooks.PostRemove,
+		&profile.Hooks.PostApply,
+	} {
+		if *hooks == nil {
+			*hooks = []Hook{}
+		}
+	}
+	return ProfileInfo{Name: name, Default: isDefault, Profile: profile}
+}
+
+// ValidateOutputFormat returns an error if format is not a supported output format.
+func ValidateOutputFormat(format string) error {
+	switch format {
+	case OutputText, OutputJSON, OutputYAML:
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q (use text, json or yaml)", format)
+	}
+}
+
+// WriteOutput encodes v to w in the given machine-readable format.
+func WriteOutput(w io.Writer, format string, v any) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("failed to encode JSON output: %w", err)
+		}
+	case OutputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("failed to encode YAML output: %w", err)
+		}
+		if err := enc.Close(); err != nil {
+			return fmt.Errorf("failed to encode YAML output: %w", err)
+		}
+	default:
+		return fmt.Errorf("output format %q is not machine-readable", format)
+	}
+	return nil
+}
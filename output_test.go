@@ -0,0 +1,113 @@
+package mangrove
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidateOutputFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		wantErr bool
+	}{
+		{format: "text"},
+		{format: "json"},
+		{format: "yaml"},
+		{format: "xml", wantErr: true},
+		{format: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			err := ValidateOutputFormat(tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOutputFormat(%q) error = %v, wantErr %v", tt.format, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteOutputWorkspaceInfo(t *testing.T) {
+	ws := WorkspaceInfo{
+		ProfileName:   "proj",
+		WorkspaceName: "feature-x",
+		Path:          "/ws/proj/feature-x",
+		RepoStatuses: []RepoStatus{
+			{RepoName: "api", BranchName: "feature-x", ChangedCount: 2, Ahead: 1, DefaultBase: "develop", Exists: true},
+		},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteOutput(&buf, OutputJSON, ws); err != nil {
+			t.Fatalf("WriteOutput() unexpected error: %v", err)
+		}
+
+		var got map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+		}
+		for _, key := range []string{"profile", "name", "path", "repos"} {
+			if _, ok := got[key]; !ok {
+				t.Errorf("JSON output missing key %q: %s", key, buf.String())
+			}
+		}
+		if _, ok := got["metadata"]; ok {
+			t.Errorf("JSON output should omit nil metadata: %s", buf.String())
+		}
+		repo := got["repos"].([]any)[0].(map[string]any)
+		for _, key := range []string{"repo", "branch", "changed", "ahead", "behind", "base", "exists"} {
+			if _, ok := repo[key]; !ok {
+				t.Errorf("JSON repo status missing key %q: %s", key, buf.String())
+			}
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteOutput(&buf, OutputYAML, ws); err != nil {
+			t.Fatalf("WriteOutput() unexpected error: %v", err)
+		}
+
+		var got WorkspaceInfo
+		if err := yaml.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("output is not valid YAML: %v\n%s", err, buf.String())
+		}
+		if got.WorkspaceName != ws.WorkspaceName || len(got.RepoStatuses) != 1 || got.RepoStatuses[0] != ws.RepoStatuses[0] {
+			t.Errorf("YAML round trip = %+v, want %+v", got, ws)
+		}
+		if !strings.Contains(buf.String(), "changed: 2") {
+			t.Errorf("YAML output should use snake_case field names:\n%s", buf.String())
+		}
+	})
+
+	t.Run("text is rejected", func(t *testing.T) {
+		if err := WriteOutput(&bytes.Buffer{}, OutputText, ws); err == nil {
+			t.Error("WriteOutput() expected error for text format")
+		}
+	})
+}
+
+func TestNewProfileInfo(t *testing.T) {
+	info := NewProfileInfo("proj", Profile{}, true)
+
+	var buf bytes.Buffer
+	if err := WriteOutput(&buf, OutputJSON, info); err != nil {
+		t.Fatalf("WriteOutput() unexpected error: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if got["name"] != "proj" || got["default"] != true {
+		t.Errorf("profile JSON = %s, want name proj and default true", buf.String())
+	}
+	if repos, ok := got["repos"].([]any); !ok || len(repos) != 0 {
+		t.Errorf("profile JSON repos = %v, want empty array", got["repos"])
+	}
+}
@@ -0,0 +1,31 @@
+package mangrove
+
+import "sync"
+
+// DefaultParallelism is the number of repos processed concurrently when the
+// config does not set parallel.
+const DefaultParallelism = 4
+
+// RunParallel calls fn for every index in [0, count) using at most limit
+// concurrent goroutines, and waits for all calls to finish.
+// A limit of 0 or less runs everything at once.
+func RunParallel(count, limit int, fn func(i int)) {
+	if limit <= 0 || limit > count {
+		limit = count
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(limit, 1))
+
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+
+	wg.Wait()
+}
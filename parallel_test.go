@@ -0,0 +1,53 @@
+package mangrove
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunParallel(t *testing.T) {
+	tests := []struct {
+		name    string
+		count   int
+		limit   int
+		wantMax int32
+	}{
+		{name: "bounded by limit", count: 8, limit: 3, wantMax: 3},
+		{name: "limit larger than count", count: 2, limit: 10, wantMax: 2},
+		{name: "zero limit runs all at once", count: 5, limit: 0, wantMax: 5},
+		{name: "no items", count: 0, limit: 4, wantMax: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, peak int32
+			var mu sync.Mutex
+			seen := make(map[int]bool)
+
+			RunParallel(tt.count, tt.limit, func(i int) {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+
+				mu.Lock()
+				seen[i] = true
+				mu.Unlock()
+			})
+
+			if len(seen) != tt.count {
+				t.Errorf("RunParallel called fn for %d indexes, want %d", len(seen), tt.count)
+			}
+			if peak > tt.wantMax {
+				t.Errorf("RunParallel peak concurrency = %d, want at most %d", peak, tt.wantMax)
+			}
+		})
+	}
+}
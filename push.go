@@ -0,0 +1,92 @@
+package mangrove
+
+import "fmt"
+
+// DefaultRemote is the remote pushed to when a branch has no remote configured.
+const DefaultRemote = "origin"
+
+// PushTarget is a worktree whose branch should be pushed, labelled by repo name.
+type PushTarget struct {
+	Name   string
+	Dir    string
+	Branch string
+	// Base is compared against when the branch has not been pushed yet.
+	Base string
+	// Remote overrides the branch's configured remote. The branch is then compared
+	// against <Remote>/<Branch> instead of its upstream.
+	Remote string
+}
+
+// PushResult is the outcome of pushing one repo.
+type PushResult struct {
+	RepoResult `yaml:",inline"`
+	Branch     string `json:"branch"           yaml:"branch"`
+	Remote     string `json:"remote"           yaml:"remote"`
+	Commit     string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	Ahead      int    `json:"ahead"            yaml:"ahead"`
+}
+
+// PushRepos pushes each target's branch with --set-upstream, up to limit at once.
+// Branches with nothing ahead of their upstream, or of <remote>/<branch> when the
+// remote is overridden (or of their base when not pushed yet), are skipped. A failure in one repo does not stop the others.
+func PushRepos(targets []PushTarget, forceWithLease bool, limit int) []PushResult {
+	results := make([]PushResult, len(targets))
+	RunParallel(len(targets), limit, func(i int) {
+		results[i] = pushRepo(targets[i], forceWithLease)
+		results[i].setError()
+	})
+	return results
+}
+
+// pushRepo pushes the branch of a single target.
+func pushRepo(t PushTarget, forceWithLease bool) PushResult {
+	result := PushResult{RepoResult: RepoResult{Name: t.Name}, Branch: t.Branch, Remote: t.Remote}
+	if result.Remote == "" {
+		result.Remote = BranchRemote(t.Dir, t.Branch)
+	}
+	if result.Remote == "" {
+		result.Remote = DefaultRemote
+	}
+
+	// Compare against the ref the push updates: the upstream, or the branch on
+	// the overriding remote
+	var upstream string
+	if t.Remote != "" {
+		if ref := t.Remote + "/" + t.Branch; RefExists(t.Dir, "refs/remotes/"+ref) {
+			upstream = ref
+		}
+	} else if u, err := Upstream(t.Dir, t.Branch); err == nil {
+		upstream = u
+	}
+
+	if upstream != "" {
+		ahead, _, err := AheadBehind(t.Dir, upstream, t.Branch)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		result.Ahead = ahead
+		if ahead == 0 {
+			result.Skipped = true
+			result.Reason = fmt.Sprintf("up to date with %s", upstream)
+			return result
+		}
+	} else if t.Base != "" {
+		// Not pushed yet: only push if there is work of its own
+		if ahead, _, err := AheadBehind(t.Dir, t.Base, t.Branch); err == nil {
+			result.Ahead = ahead
+			if ahead == 0 {
+				result.Skipped = true
+				result.Reason = fmt.Sprintf("nothing ahead of %s", t.Base)
+				return result
+			}
+		}
+	}
+
+	if err := Push(t.Dir, result.Remote, t.Branch, forceWithLease); err != nil {
+		result.Err = err
+		return result
+	}
+	result.Commit, result.Err = RevParse(t.Dir, t.Branch)
+	return result
+}
@@ -0,0 +1,90 @@
+package mangrove
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPushRepos(t *testing.T) {
+	remote := t.TempDir()
+	runGit(t, remote, "init", "-q", "--bare")
+
+	api := newTestRepo(t)
+	runGit(t, api, "remote", "add", "origin", remote)
+	runGit(t, api, "push", "-q", "origin", "main")
+	web := newTestRepo(t)
+	runGit(t, web, "remote", "add", "origin", remote)
+
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: []Repo{{Name: "api", Path: api}, {Name: "web", Path: web}}}
+	if err := CreateWorkspace(cfg, profile, "proj", "feat", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	apiDir := filepath.Join(GetWorkspacePath(cfg, "proj", "feat"), "api")
+	webDir := filepath.Join(GetWorkspacePath(cfg, "proj", "feat"), "web")
+	writeAndCommit(t, apiDir, "feature.txt", "v1\n", "add feature")
+
+	targets := []PushTarget{
+		{Name: "api", Dir: apiDir, Branch: "feat", Base: "main"},
+		{Name: "web", Dir: webDir, Branch: "feat", Base: "main"},
+	}
+
+	results := PushRepos(targets, false, 2)
+	if failed := CountFailed(results); failed != 0 {
+		t.Fatalf("PushRepos() failed in %d repos: %+v", failed, results)
+	}
+	head, _ := RevParse(apiDir, "HEAD")
+	if r := results[0]; r.Skipped || r.Commit != head || r.Remote != "origin" || r.Ahead != 1 {
+		t.Errorf("api result = %+v, want pushed %s to origin", r, head)
+	}
+	if got := runGit(t, remote, "rev-parse", "feat"); got != head {
+		t.Errorf("remote feat = %s, want %s", got, head)
+	}
+	if upstream, err := Upstream(apiDir, "feat"); err != nil || upstream != "origin/feat" {
+		t.Errorf("Upstream() = %q, %v; want origin/feat", upstream, err)
+	}
+	if r := results[1]; !r.Skipped {
+		t.Errorf("web result = %+v, want skipped with nothing ahead of main", r)
+	}
+
+	// Nothing new to push
+	results = PushRepos(targets[:1], false, 1)
+	if r := results[0]; !r.Skipped || r.Err != nil {
+		t.Errorf("second push = %+v, want skipped as up to date", r)
+	}
+
+	// A rewritten branch needs --force-with-lease
+	runGit(t, apiDir, "commit", "-q", "--amend", "-m", "add feature (amended)")
+	amended, _ := RevParse(apiDir, "HEAD")
+
+	results = PushRepos(targets[:1], false, 1)
+	if results[0].Err == nil {
+		t.Error("push of a rewritten branch without --force-with-lease should fail")
+	}
+	results = PushRepos(targets[:1], true, 1)
+	if r := results[0]; r.Err != nil || r.Commit != amended {
+		t.Errorf("force push = %+v, want %s", r, amended)
+	}
+	if got := runGit(t, remote, "rev-parse", "feat"); got != amended {
+		t.Errorf("remote feat = %s, want %s", got, amended)
+	}
+
+	// An overriding remote is compared against its own branch, not the upstream
+	fork := t.TempDir()
+	runGit(t, fork, "init", "-q", "--bare")
+	runGit(t, api, "remote", "add", "fork", fork)
+	forkTarget := targets[0]
+	forkTarget.Remote = "fork"
+
+	results = PushRepos([]PushTarget{forkTarget}, false, 1)
+	if r := results[0]; r.Skipped || r.Err != nil || r.Remote != "fork" {
+		t.Errorf("push to fork = %+v, want pushed although up to date with origin", r)
+	}
+	if got := runGit(t, fork, "rev-parse", "feat"); got != amended {
+		t.Errorf("fork feat = %s, want %s", got, amended)
+	}
+	results = PushRepos([]PushTarget{forkTarget}, false, 1)
+	if r := results[0]; !r.Skipped || r.Reason != "up to date with fork/feat" {
+		t.Errorf("second push to fork = %+v, want skipped as up to date with fork/feat", r)
+	}
+}
@@ -0,0 +1,157 @@
+package mangrove
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// RenameWorkspace renames a workspace: branches named after the workspace are
+// renamed in every repo, the worktrees are moved with git worktree move, the rest
+// of the directory follows, and the metadata is updated. Branches that do not
+// follow branch_template (e.g., checked-out existing branches) keep their names.
+// If any step fails, every step already done is undone in reverse order.
+func RenameWorkspace(cfg *Config, profile *Profile, profileName, oldName, newName string) (err error) {
+	if oldName == newName {
+		return fmt.Errorf("workspace is already named %q", newName)
+	}
+	if err := ValidateWorkspaceName(newName); err != nil {
+		return err
+	}
+
+	oldPath := GetWorkspacePath(cfg, profileName, oldName)
+	newPath := GetWorkspacePath(cfg, profileName, newName)
+
+	if _, err := os.Stat(oldPath); os.IsNotExist(err) {
+		return fmt.Errorf("workspace %q not found at %s", oldName, oldPath)
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return fmt.Errorf("workspace %q already exists at %s", newName, newPath)
+	}
+
+	if err := CheckInProgress(oldPath, oldName); err != nil {
+		return err
+	}
+
+	meta, err := LoadWorkspaceMetadata(oldPath)
+	if err != nil {
+		return err
+	}
+	if meta == nil {
+		if meta, err = newWorkspaceMetadata(profile, profileName, oldName, oldPath); err != nil {
+			return err
+		}
+	}
+
+	repos := WorkspaceRepos(meta, profile)
+	for _, repo := range repos {
+		if _, err := os.Stat(filepath.Join(oldPath, repo.Name)); err != nil {
+			return fmt.Errorf("worktree for %s not found. Run mgv doctor to repair the workspace", repo.Name)
+		}
+	}
+
+	// Every step registers how to undo it; on failure they run in reverse
+	var undo []func() error
+	defer func() {
+		if err == nil {
+			return
+		}
+		PrintWarning("Rolling back rename...")
+		var errs []error
+		for i := len(undo) - 1; i >= 0; i-- {
+			if uerr := undo[i](); uerr != nil {
+				errs = append(errs, uerr)
+			}
+		}
+		if rerr := errors.Join(errs...); rerr != nil {
+			err = fmt.Errorf("%w\nrollback failed: %v", err, rerr)
+		}
+	}()
+
+	fmt.Fprintf(os.Stderr, "\nRenaming workspace: %s/%s → %s\n", profileName, oldName, newName)
+
+	// Rename the branches that were named after the workspace
+	for _, repo := range repos {
+		oldBranch, berr := WorkspaceBranch(meta, profile, &repo, profileName, oldName)
+		if berr != nil {
+			return berr
+		}
+		templated, terr := profile.BranchName(&repo, profileName, oldName)
+		if terr != nil || oldBranch != templated {
+			PrintInfo("%s  keeping branch %s", repo.Name, oldBranch)
+			continue
+		}
+		newBranch, berr := profile.BranchName(&repo, profileName, newName)
+		if berr != nil {
+			return berr
+		}
+
+		if err := BranchRename(repo.Path, oldBranch, newBranch); err != nil {
+			return fmt.Errorf("%s: %w", repo.Name, err)
+		}
+		undo = append(undo, func() error {
+			return BranchRename(repo.Path, newBranch, oldBranch)
+		})
+		if rm := meta.FindRepo(repo.Name); rm != nil {
+			rm.Branch = newBranch
+		}
+		PrintSuccess("%s  %s → %s", RepoNameStyle.Render(repo.Name), BranchNameStyle.Render(oldBranch), BranchNameStyle.Render(newBranch))
+	}
+
+	// Move the worktrees into the new directory
+	if err := os.MkdirAll(newPath, 0o755); err != nil {
+		return fmt.Errorf("failed to create workspace directory: %w", err)
+	}
+	undo = append(undo, func() error {
+		return os.Remove(newPath)
+	})
+
+	for _, repo := range repos {
+		from := filepath.Join(oldPath, repo.Name)
+		to := filepath.Join(newPath, repo.Name)
+		if err := WorktreeMove(repo.Path, from, to); err != nil {
+			return fmt.Errorf("%s: %w", repo.Name, err)
+		}
+		undo = append(undo, func() error {
+			return WorktreeMove(repo.Path, to, from)
+		})
+
+		// Keep the branch's recorded worktree in step for mgv doctor
+		branch, _ := CurrentBranch(to)
+		if recorded, _ := WorktreeBranches(repo.Path); recorded[branch] == from {
+			_ = BranchSetWorktree(repo.Path, branch, to)
+			undo = append(undo, func() error {
+				return BranchSetWorktree(repo.Path, branch, from)
+			})
+		}
+	}
+
+	// Move everything else (metadata, user files) and remove the old directory
+	entries, err := os.ReadDir(oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to read workspace directory: %w", err)
+	}
+	for _, entry := range entries {
+		from := filepath.Join(oldPath, entry.Name())
+		to := filepath.Join(newPath, entry.Name())
+		if err := os.Rename(from, to); err != nil {
+			return fmt.Errorf("failed to move %s: %w", entry.Name(), err)
+		}
+		undo = append(undo, func() error {
+			return os.Rename(to, from)
+		})
+	}
+
+	meta.Name = newName
+	if err := SaveWorkspaceMetadata(newPath, meta); err != nil {
+		return err
+	}
+
+	if err := os.Remove(oldPath); err != nil {
+		PrintWarning("Failed to remove %s: %v", oldPath, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nWorkspace renamed: %s\n", newPath)
+	return nil
+}
@@ -0,0 +1,119 @@
+package mangrove
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenameWorkspace(t *testing.T) {
+	repos := []Repo{
+		{Name: "api", Path: newTestRepo(t)},
+		{Name: "web", Path: newTestRepo(t), BranchTemplate: "feature/{{.Workspace}}"},
+	}
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "old", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	oldPath := GetWorkspacePath(cfg, "proj", "old")
+	if err := os.WriteFile(filepath.Join(oldPath, "notes.txt"), []byte("todo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RenameWorkspace(cfg, profile, "proj", "old", "new"); err != nil {
+		t.Fatalf("RenameWorkspace() unexpected error: %v", err)
+	}
+
+	newPath := GetWorkspacePath(cfg, "proj", "new")
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Errorf("old workspace directory should be removed")
+	}
+	if _, err := os.Stat(filepath.Join(newPath, "notes.txt")); err != nil {
+		t.Errorf("user files should move with the workspace: %v", err)
+	}
+
+	want := map[string]string{"api": "new", "web": "feature/new"}
+	for name, branch := range want {
+		got, err := CurrentBranch(filepath.Join(newPath, name))
+		if err != nil {
+			t.Fatalf("CurrentBranch(%s) unexpected error: %v", name, err)
+		}
+		if got != branch {
+			t.Errorf("%s branch = %q, want %q", name, got, branch)
+		}
+	}
+
+	meta, err := LoadWorkspaceMetadata(newPath)
+	if err != nil || meta == nil {
+		t.Fatalf("LoadWorkspaceMetadata() = %v, %v; want metadata", meta, err)
+	}
+	if meta.Name != "new" || meta.FindRepo("web").Branch != "feature/new" {
+		t.Errorf("metadata = %+v, want name new and web branch feature/new", meta)
+	}
+
+	// The moved worktrees are still registered and removable
+	if err := RemoveWorkspace(cfg, profile, "proj", "new", true, false); err != nil {
+		t.Fatalf("RemoveWorkspace() unexpected error: %v", err)
+	}
+}
+
+func TestRenameWorkspaceRollback(t *testing.T) {
+	repos := []Repo{
+		{Name: "api", Path: newTestRepo(t)},
+		{Name: "web", Path: newTestRepo(t)},
+	}
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "old", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+
+	// The target branch already exists in the second repo
+	runGit(t, repos[1].Path, "branch", "new", "main")
+
+	if err := RenameWorkspace(cfg, profile, "proj", "old", "new"); err == nil {
+		t.Fatal("RenameWorkspace() expected error for an existing target branch")
+	}
+
+	oldPath := GetWorkspacePath(cfg, "proj", "old")
+	for _, repo := range repos {
+		got, err := CurrentBranch(filepath.Join(oldPath, repo.Name))
+		if err != nil {
+			t.Fatalf("CurrentBranch(%s) unexpected error: %v", repo.Name, err)
+		}
+		if got != "old" {
+			t.Errorf("%s branch after rollback = %q, want old", repo.Name, got)
+		}
+	}
+	if RefExists(repos[0].Path, "refs/heads/new") {
+		t.Error("api branch rename should be rolled back")
+	}
+	if _, err := os.Stat(GetWorkspacePath(cfg, "proj", "new")); !os.IsNotExist(err) {
+		t.Error("new workspace directory should not exist after rollback")
+	}
+}
+
+func TestRenameWorkspaceInvalidName(t *testing.T) {
+	repo := newTestRepo(t)
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: []Repo{{Name: "api", Path: repo}}}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "old", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"../escape", "a/b"} {
+		if err := RenameWorkspace(cfg, profile, "proj", "old", name); err == nil {
+			t.Errorf("RenameWorkspace(%q) expected error, got nil", name)
+		}
+	}
+	if !RefExists(repo, "refs/heads/old") {
+		t.Error("branch old should be left untouched")
+	}
+	if _, err := os.Stat(filepath.Join(GetWorkspacePath(cfg, "proj", "old"), "api")); err != nil {
+		t.Errorf("worktree should be left in place: %v", err)
+	}
+}
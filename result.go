@@ -0,0 +1,40 @@
+package mangrove
+
+// RepoResult is the outcome of a multi-repo command in one repo. The per-command
+// result types embed it and add their own details.
+type RepoResult struct {
+	Name    string `json:"repo"              yaml:"repo"`
+	Skipped bool   `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+	// Reason explains why a repo was skipped.
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Err    error  `json:"-"                yaml:"-"`
+	// Error is Err as a string for machine output.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// repoResulter is implemented by every type that embeds RepoResult.
+type repoResulter interface {
+	repoResult() RepoResult
+}
+
+func (r RepoResult) repoResult() RepoResult {
+	return r
+}
+
+// setError copies Err into Error for machine output.
+func (r *RepoResult) setError() {
+	if r.Err != nil {
+		r.Error = r.Err.Error()
+	}
+}
+
+// CountFailed returns the number of results that did not succeed.
+func CountFailed[R repoResulter](results []R) int {
+	failed := 0
+	for _, r := range results {
+		if r.repoResult().Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
@@ -0,0 +1,131 @@
+package mangrove
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// tagPrefix marks a selector pattern that matches repos by tag instead of name.
+const tagPrefix = "tag:"
+
+// RepoSelector selects a subset of repos by name, glob or tag.
+// Patterns are matched against repo names with path.Match; a pattern of the
+// form "tag:<name>" matches repos that carry that tag.
+type RepoSelector struct {
+	Include []string
+	Exclude []string
+}
+
+// IsEmpty reports whether the selector selects every repo.
+func (s RepoSelector) IsEmpty() bool {
+	return len(s.Include) == 0 && len(s.Exclude) == 0
+}
+
+// Select returns the repos that match any include pattern (or all repos when
+// there are none) and no exclude pattern, preserving their order.
+// It returns an error if a pattern is malformed or an include pattern matches nothing.
+func (s RepoSelector) Select(repos []Repo) ([]Repo, error) {
+	for _, pattern := range append(slices.Clone(s.Include), s.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid repo pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, pattern := range s.Include {
+		found := false
+		for i := range repos {
+			if matchRepo(&repos[i], pattern) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no repo matches %q", pattern)
+		}
+	}
+
+	selected := make([]Repo, 0, len(repos))
+	for i := range repos {
+		repo := &repos[i]
+		if len(s.Include) > 0 && !matchAny(repo, s.Include) {
+			continue
+		}
+		if matchAny(repo, s.Exclude) {
+			continue
+		}
+		selected = append(selected, *repo)
+	}
+	return selected, nil
+}
+
+// matchAny reports whether the repo matches any of the patterns.
+func matchAny(repo *Repo, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matchRepo(repo, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRepo reports whether the repo matches a single pattern.
+func matchRepo(repo *Repo, pattern string) bool {
+	if tag, ok := strings.CutPrefix(pattern, tagPrefix); ok {
+		return slices.Contains(repo.Tags, tag)
+	}
+	matched, _ := path.Match(pattern, repo.Name)
+	return matched
+}
+
+// RepoNames returns the names of the given repos.
+func RepoNames(repos []Repo) []string {
+	names := make([]string, len(repos))
+	for i, r := range repos {
+		names[i] = r.Name
+	}
+	return names
+}
+
+// FilterReposByName returns the repos whose names are in names, preserving repo order.
+func FilterReposByName(repos []Repo, names []string) []Repo {
+	filtered := make([]Repo, 0, len(names))
+	for _, r := range repos {
+		if slices.Contains(names, r.Name) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// FindRepo returns the repo with the given name, or nil if there is none.
+func FindRepo(repos []Repo, name string) *Repo {
+	for i := range repos {
+		if repos[i].Name == name {
+			return &repos[i]
+		}
+	}
+	return nil
+}
+
+// WorkspaceRepos returns the repos that belong to a workspace. When the workspace
+// has metadata, only the repos recorded in it are returned (a workspace may have been
+// created with a subset of the profile), along with repos added from outside the
+// profile; otherwise all profile repos are.
+func WorkspaceRepos(meta *WorkspaceMetadata, profile *Profile) []Repo {
+	if meta == nil {
+		return profile.Repos
+	}
+	names := make([]string, len(meta.Repos))
+	for i, rm := range meta.Repos {
+		names[i] = rm.Name
+	}
+	repos := FilterReposByName(profile.Repos, names)
+	for _, rm := range meta.Repos {
+		if rm.Path != "" && FindRepo(repos, rm.Name) == nil {
+			repos = append(repos, Repo{Name: rm.Name, Path: rm.Path, DefaultBase: rm.Base})
+		}
+	}
+	return repos
+}
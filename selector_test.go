@@ -0,0 +1,104 @@
+package mangrove
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRepoSelectorSelect(t *testing.T) {
+	repos := []Repo{
+		{Name: "api", Tags: []string{"backend"}},
+		{Name: "web-app", Tags: []string{"frontend"}},
+		{Name: "web-admin", Tags: []string{"frontend", "legacy"}},
+		{Name: "infra"},
+	}
+
+	tests := []struct {
+		name     string
+		selector RepoSelector
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name: "empty selects all",
+			want: []string{"api", "web-app", "web-admin", "infra"},
+		},
+		{
+			name:     "exact name",
+			selector: RepoSelector{Include: []string{"infra"}},
+			want:     []string{"infra"},
+		},
+		{
+			name:     "glob",
+			selector: RepoSelector{Include: []string{"web-*"}},
+			want:     []string{"web-app", "web-admin"},
+		},
+		{
+			name:     "tag",
+			selector: RepoSelector{Include: []string{"tag:backend"}},
+			want:     []string{"api"},
+		},
+		{
+			name:     "include keeps repo order",
+			selector: RepoSelector{Include: []string{"infra", "api"}},
+			want:     []string{"api", "infra"},
+		},
+		{
+			name:     "exclude by tag",
+			selector: RepoSelector{Include: []string{"tag:frontend"}, Exclude: []string{"tag:legacy"}},
+			want:     []string{"web-app"},
+		},
+		{
+			name:     "exclude only",
+			selector: RepoSelector{Exclude: []string{"web-*"}},
+			want:     []string{"api", "infra"},
+		},
+		{
+			name:     "exclude everything",
+			selector: RepoSelector{Exclude: []string{"*"}},
+			want:     []string{},
+		},
+		{
+			name:     "unmatched include",
+			selector: RepoSelector{Include: []string{"mobile"}},
+			wantErr:  true,
+		},
+		{
+			name:     "invalid pattern",
+			selector: RepoSelector{Exclude: []string{"web-["}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.selector.Select(repos)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Select() = %v, want error", RepoNames(got))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Select() unexpected error: %v", err)
+			}
+			if names := RepoNames(got); !slices.Equal(names, tt.want) {
+				t.Errorf("Select() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkspaceRepos(t *testing.T) {
+	profile := &Profile{Repos: []Repo{{Name: "api"}, {Name: "web"}, {Name: "infra"}}}
+
+	if got := RepoNames(WorkspaceRepos(nil, profile)); !slices.Equal(got, []string{"api", "web", "infra"}) {
+		t.Errorf("WorkspaceRepos(nil) = %v, want all profile repos", got)
+	}
+
+	// Repos recorded in metadata but no longer in the profile are dropped
+	meta := &WorkspaceMetadata{Repos: []RepoMetadata{{Name: "infra"}, {Name: "api"}, {Name: "gone"}}}
+	if got := RepoNames(WorkspaceRepos(meta, profile)); !slices.Equal(got, []string{"api", "infra"}) {
+		t.Errorf("WorkspaceRepos(meta) = %v, want [api infra]", got)
+	}
+}
@@ -0,0 +1,326 @@
+package mangrove
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SyncStateFileName is the name of the file that tracks an in-progress sync in the workspace root.
+const SyncStateFileName = ".mgv-sync.yaml"
+
+// Sync strategies.
+const (
+	SyncRebase = "rebase"
+	SyncMerge  = "merge"
+)
+
+// Sync repo states.
+const (
+	syncPending  = "pending"
+	syncDone     = "done"
+	syncConflict = "conflict"
+)
+
+// SyncTarget describes a repo worktree to bring up to date with its base.
+type SyncTarget struct {
+	RepoName string
+	RepoPath string
+	Worktree string
+	Base     string
+}
+
+// SyncRepoState records the progress of a single repo within a sync.
+type SyncRepoState struct {
+	Name     string `yaml:"name"`
+	Worktree string `yaml:"worktree"`
+	Onto     string `yaml:"onto"`
+	OrigHead string `yaml:"orig_head"`
+	Status   string `yaml:"status"`
+}
+
+// SyncState is the persisted state of an in-progress sync, used by --continue and --abort.
+type SyncState struct {
+	Strategy string          `yaml:"strategy"`
+	Repos    []SyncRepoState `yaml:"repos"`
+}
+
+// SyncConflictError is returned when a sync stops on a conflict.
+type SyncConflictError struct {
+	RepoName string
+	Worktree string
+	Onto     string
+	Strategy string
+}
+
+func (e *SyncConflictError) Error() string {
+	return fmt.Sprintf("%s: conflict while %s onto %s", e.RepoName, syncVerb(e.Strategy), e.Onto)
+}
+
+// ErrNoSyncInProgress is returned by ContinueSync and AbortSync when there is nothing to resume.
+var ErrNoSyncInProgress = errors.New("no sync in progress")
+
+// LoadSyncState reads the sync state of a workspace.
+// Returns nil without error if no sync is in progress.
+func LoadSyncState(wsPath string) (*SyncState, error) {
+	data, err := os.ReadFile(filepath.Join(wsPath, SyncStateFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	var state SyncState
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state: %w", err)
+	}
+	return &state, nil
+}
+
+// saveSyncState writes the sync state of a workspace.
+func saveSyncState(wsPath string, state *SyncState) error {
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync state: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(wsPath, SyncStateFileName), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
+
+// removeSyncState deletes the sync state file of a workspace.
+func removeSyncState(wsPath string) error {
+	err := os.Remove(filepath.Join(wsPath, SyncStateFileName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove sync state: %w", err)
+	}
+	return nil
+}
+
+// StartSync fetches every target repo and then rebases or merges each worktree
+// branch onto its base, one repo at a time. It stops on the first conflict and
+// returns a *SyncConflictError, leaving the conflict in place for the user to resolve.
+func StartSync(wsPath string, targets []SyncTarget, strategy string, parallel int) error {
+	if strategy != SyncRebase && strategy != SyncMerge {
+		return fmt.Errorf("unknown sync strategy %q (use rebase or merge)", strategy)
+	}
+
+	existing, err := LoadSyncState(wsPath)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("a sync is already in progress. Use --continue or --abort")
+	}
+	if state, err := LoadApplyState(wsPath); err != nil {
+		return err
+	} else if state != nil {
+		return fmt.Errorf("an apply is in progress. Finish it with mgv apply --continue or --abort first")
+	}
+
+	// Refuse to start with uncommitted changes: rebase and merge both need a clean tree,
+	// and --abort resets repos to their original HEAD.
+	for _, t := range targets {
+		count, err := StatusChangedCount(t.Worktree)
+		if err != nil {
+			return fmt.Errorf("%s: failed to get status: %w", t.RepoName, err)
+		}
+		if count > 0 {
+			return fmt.Errorf("%s has uncommitted changes (%d files). Commit or stash them first", t.RepoName, count)
+		}
+	}
+
+	errs := make([]error, len(targets))
+	RunParallel(len(targets), parallel, func(i int) {
+		if err := FetchAll(targets[i].RepoPath); err != nil {
+			errs[i] = fmt.Errorf("%s: %w", targets[i].RepoName, err)
+		}
+	})
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	state := &SyncState{Strategy: strategy}
+	for _, t := range targets {
+		head, err := RevParse(t.Worktree, "HEAD")
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.RepoName, err)
+		}
+		state.Repos = append(state.Repos, SyncRepoState{
+			Name:     t.RepoName,
+			Worktree: t.Worktree,
+			Onto:     syncUpstream(t.RepoPath, t.Base),
+			OrigHead: head,
+			Status:   syncPending,
+		})
+	}
+
+	if err := saveSyncState(wsPath, state); err != nil {
+		return err
+	}
+	return runSync(wsPath, state)
+}
+
+// ContinueSync resumes a sync after the user has resolved and staged the conflict.
+func ContinueSync(wsPath string) error {
+	state, err := LoadSyncState(wsPath)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return ErrNoSyncInProgress
+	}
+
+	for i := range state.Repos {
+		rs := &state.Repos[i]
+		if rs.Status != syncConflict {
+			continue
+		}
+
+		var err error
+		switch {
+		case state.Strategy == SyncRebase && IsRebaseInProgress(rs.Worktree):
+			err = RebaseContinue(rs.Worktree)
+		case state.Strategy == SyncMerge && IsMergeInProgress(rs.Worktree):
+			err = MergeContinue(rs.Worktree)
+		}
+		if err != nil {
+			if syncInProgress(state.Strategy, rs.Worktree) {
+				return &SyncConflictError{RepoName: rs.Name, Worktree: rs.Worktree, Onto: rs.Onto, Strategy: state.Strategy}
+			}
+			return fmt.Errorf("%s: %w", rs.Name, err)
+		}
+
+		rs.Status = syncDone
+		PrintSuccess("%s  %s onto %s", RepoNameStyle.Render(rs.Name), syncPastVerb(state.Strategy), BranchNameStyle.Render(rs.Onto))
+		if err := saveSyncState(wsPath, state); err != nil {
+			return err
+		}
+	}
+
+	return runSync(wsPath, state)
+}
+
+// AbortSync aborts an in-progress sync and restores every repo to the HEAD it had before the sync started.
+func AbortSync(wsPath string) error {
+	state, err := LoadSyncState(wsPath)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return ErrNoSyncInProgress
+	}
+
+	var errs []error
+	for _, rs := range state.Repos {
+		switch rs.Status {
+		case syncConflict:
+			var err error
+			if state.Strategy == SyncRebase && IsRebaseInProgress(rs.Worktree) {
+				err = RebaseAbort(rs.Worktree)
+			} else if state.Strategy == SyncMerge && IsMergeInProgress(rs.Worktree) {
+				err = MergeAbort(rs.Worktree)
+			}
+			if err == nil {
+				err = ResetHard(rs.Worktree, rs.OrigHead)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", rs.Name, err))
+				continue
+			}
+		case syncDone:
+			if err := ResetHard(rs.Worktree, rs.OrigHead); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", rs.Name, err))
+				continue
+			}
+		default:
+			continue
+		}
+		PrintSuccess("%s  restored to %s", RepoNameStyle.Render(rs.Name), shortHash(rs.OrigHead))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return removeSyncState(wsPath)
+}
+
+// runSync processes every pending repo in order, stopping on the first conflict.
+func runSync(wsPath string, state *SyncState) error {
+	for i := range state.Repos {
+		rs := &state.Repos[i]
+		if rs.Status != syncPending {
+			continue
+		}
+
+		var err error
+		if state.Strategy == SyncRebase {
+			err = Rebase(rs.Worktree, rs.Onto)
+		} else {
+			err = Merge(rs.Worktree, rs.Onto)
+		}
+		if err != nil {
+			if syncInProgress(state.Strategy, rs.Worktree) {
+				rs.Status = syncConflict
+				if serr := saveSyncState(wsPath, state); serr != nil {
+					return serr
+				}
+				return &SyncConflictError{RepoName: rs.Name, Worktree: rs.Worktree, Onto: rs.Onto, Strategy: state.Strategy}
+			}
+			return fmt.Errorf("%s: %w", rs.Name, err)
+		}
+
+		rs.Status = syncDone
+		PrintSuccess("%s  %s onto %s", RepoNameStyle.Render(rs.Name), syncPastVerb(state.Strategy), BranchNameStyle.Render(rs.Onto))
+		if err := saveSyncState(wsPath, state); err != nil {
+			return err
+		}
+	}
+
+	return removeSyncState(wsPath)
+}
+
+// syncUpstream returns origin/<base> if it exists, falling back to the local base branch.
+func syncUpstream(repoPath, base string) string {
+	if RefExists(repoPath, "refs/remotes/origin/"+base) {
+		return "origin/" + base
+	}
+	return base
+}
+
+// syncInProgress reports whether the worktree is stopped in the middle of a rebase or merge.
+func syncInProgress(strategy, worktree string) bool {
+	if strategy == SyncRebase {
+		return IsRebaseInProgress(worktree)
+	}
+	return IsMergeInProgress(worktree)
+}
+
+// syncVerb returns the progressive verb for a sync strategy.
+func syncVerb(strategy string) string {
+	if strategy == SyncRebase {
+		return "rebasing"
+	}
+	return "merging"
+}
+
+// syncPastVerb returns the past-tense verb for a sync strategy.
+func syncPastVerb(strategy string) string {
+	if strategy == SyncRebase {
+		return "rebased"
+	}
+	return "merged"
+}
+
+// shortHash abbreviates a commit hash for display.
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
@@ -0,0 +1,133 @@
+package mangrove
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newSyncWorkspace creates a workspace with one repo and a commit in its worktree.
+func newSyncWorkspace(t *testing.T, file string) (wsPath string, target SyncTarget) {
+	t.Helper()
+
+	repoPath := newTestRepo(t)
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: []Repo{{Name: "api", Path: repoPath}}}
+	if err := CreateWorkspace(cfg, profile, "proj", "feature-x", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+
+	wsPath = GetWorkspacePath(cfg, "proj", "feature-x")
+	wt := filepath.Join(wsPath, "api")
+	writeAndCommit(t, wt, file, "feature\n", "feature change")
+
+	return wsPath, SyncTarget{RepoName: "api", RepoPath: repoPath, Worktree: wt, Base: "main"}
+}
+
+// writeAndCommit writes content to file in dir and commits it.
+func writeAndCommit(t *testing.T, dir, file, content, message string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", file, err)
+	}
+	runGit(t, dir, "add", file)
+	runGit(t, dir, "commit", "-q", "-m", message)
+}
+
+func TestStartSync(t *testing.T) {
+	for _, strategy := range []string{SyncRebase, SyncMerge} {
+		t.Run(strategy, func(t *testing.T) {
+			wsPath, target := newSyncWorkspace(t, "feature.txt")
+			writeAndCommit(t, target.RepoPath, "upstream.txt", "upstream\n", "upstream change")
+
+			if err := StartSync(wsPath, []SyncTarget{target}, strategy, 1); err != nil {
+				t.Fatalf("StartSync() unexpected error: %v", err)
+			}
+
+			_, behind, err := AheadBehind(target.RepoPath, "main", "feature-x")
+			if err != nil {
+				t.Fatalf("AheadBehind() unexpected error: %v", err)
+			}
+			if behind != 0 {
+				t.Errorf("feature-x is %d commits behind main after sync, want 0", behind)
+			}
+			if state, _ := LoadSyncState(wsPath); state != nil {
+				t.Errorf("sync state should be removed after a successful sync, got %+v", state)
+			}
+		})
+	}
+}
+
+func TestSyncConflictAbort(t *testing.T) {
+	wsPath, target := newSyncWorkspace(t, "README.md")
+	origHead, _ := RevParse(target.Worktree, "HEAD")
+	writeAndCommit(t, target.RepoPath, "README.md", "upstream\n", "upstream change")
+
+	err := StartSync(wsPath, []SyncTarget{target}, SyncRebase, 1)
+	var conflict *SyncConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("StartSync() error = %v, want *SyncConflictError", err)
+	}
+	if conflict.RepoName != "api" {
+		t.Errorf("conflict repo = %q, want %q", conflict.RepoName, "api")
+	}
+	if !IsRebaseInProgress(target.Worktree) {
+		t.Fatal("rebase should be left in progress after a conflict")
+	}
+
+	if err := StartSync(wsPath, []SyncTarget{target}, SyncRebase, 1); err == nil {
+		t.Error("StartSync() should refuse to start while a sync is in progress")
+	}
+
+	if err := AbortSync(wsPath); err != nil {
+		t.Fatalf("AbortSync() unexpected error: %v", err)
+	}
+	if IsRebaseInProgress(target.Worktree) {
+		t.Error("rebase still in progress after AbortSync()")
+	}
+	if head, _ := RevParse(target.Worktree, "HEAD"); head != origHead {
+		t.Errorf("HEAD after abort = %s, want %s", head, origHead)
+	}
+	if err := AbortSync(wsPath); !errors.Is(err, ErrNoSyncInProgress) {
+		t.Errorf("second AbortSync() error = %v, want ErrNoSyncInProgress", err)
+	}
+}
+
+func TestSyncConflictContinue(t *testing.T) {
+	wsPath, target := newSyncWorkspace(t, "README.md")
+	writeAndCommit(t, target.RepoPath, "README.md", "upstream\n", "upstream change")
+
+	if err := StartSync(wsPath, []SyncTarget{target}, SyncRebase, 1); err == nil {
+		t.Fatal("StartSync() expected conflict")
+	}
+
+	if err := os.WriteFile(filepath.Join(target.Worktree, "README.md"), []byte("resolved\n"), 0o644); err != nil {
+		t.Fatalf("failed to resolve conflict: %v", err)
+	}
+	runGit(t, target.Worktree, "add", "README.md")
+
+	if err := ContinueSync(wsPath); err != nil {
+		t.Fatalf("ContinueSync() unexpected error: %v", err)
+	}
+	if IsRebaseInProgress(target.Worktree) {
+		t.Error("rebase still in progress after ContinueSync()")
+	}
+	if _, behind, _ := AheadBehind(target.RepoPath, "main", "feature-x"); behind != 0 {
+		t.Errorf("feature-x is %d commits behind main after continue, want 0", behind)
+	}
+	if state, _ := LoadSyncState(wsPath); state != nil {
+		t.Errorf("sync state should be removed after continue completes, got %+v", state)
+	}
+}
+
+func TestStartSyncRejectsDirtyWorktree(t *testing.T) {
+	wsPath, target := newSyncWorkspace(t, "feature.txt")
+	if err := os.WriteFile(filepath.Join(target.Worktree, "dirty.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("failed to write dirty file: %v", err)
+	}
+
+	if err := StartSync(wsPath, []SyncTarget{target}, SyncRebase, 1); err == nil {
+		t.Error("StartSync() expected error for dirty worktree")
+	}
+}
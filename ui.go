@@ -3,6 +3,9 @@ package mangrove
 import (
 	"fmt"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -114,6 +117,166 @@ func FormatRepoStatusCompact(repoName string, changedCount int) string {
 	return fmt.Sprintf("[%s: %s]", repoName, ChangedBadge(changedCount))
 }
 
+// printRepoSummary prints a line per repo: the error of a failed repo, the reason a
+// skipped repo was skipped, or detail for a repo that succeeded.
+func printRepoSummary[R repoResulter](results []R, detail func(R) string) {
+	if len(results) == 0 {
+		return
+	}
+
+	width := 0
+	for _, r := range results {
+		width = max(width, len(r.repoResult().Name))
+	}
+
+	PrintHeader("Summary")
+	for _, r := range results {
+		rr := r.repoResult()
+		name := RepoNameStyle.Render(fmt.Sprintf("%-*s", width, rr.Name))
+		switch {
+		case rr.Err != nil:
+			PrintError("%s  %v", name, rr.Err)
+		case rr.Skipped:
+			fmt.Fprintf(os.Stderr, "  - %s  %s\n", name, DimStyle.Render(rr.Reason))
+		default:
+			PrintSuccess("%s  %s", name, detail(r))
+		}
+	}
+}
+
+// PrintExecSummary prints the exit code and duration of the command in each repo.
+func PrintExecSummary(results []ExecResult) {
+	printRepoSummary(results, func(r ExecResult) string {
+		return fmt.Sprintf("exit %d  %s", r.ExitCode, DimStyle.Render(r.Duration.Round(time.Millisecond).String()))
+	})
+}
+
+// PrintCommitSummary prints the commit created in each repo, or why there is none.
+func PrintCommitSummary(results []CommitResult) {
+	printRepoSummary(results, func(r CommitResult) string {
+		return fmt.Sprintf("%s  %s  %s", shortHash(r.Commit), r.Subject, DimStyle.Render(fmt.Sprintf("(%d file(s))", r.Files)))
+	})
+}
+
+// PrintPushSummary prints what was pushed in each repo, or why nothing was.
+func PrintPushSummary(results []PushResult) {
+	printRepoSummary(results, func(r PushResult) string {
+		return fmt.Sprintf("%s → %s/%s  %s", shortHash(r.Commit), r.Remote, BranchNameStyle.Render(r.Branch),
+			DimStyle.Render(fmt.Sprintf("(%d commit(s))", r.Ahead)))
+	})
+}
+
+// PrintApplyPlan prints what applying each repo would do.
+func PrintApplyPlan(plans []ApplyPlan) {
+	for _, p := range plans {
+		mode := ""
+		if p.Isolated {
+			mode = DimStyle.Render(" (isolated)")
+		}
+		fmt.Fprintf(os.Stderr, "\n[%s] %s → %s (base: %s)%s\n", RepoNameStyle.Render(p.Name), p.Method, BranchNameStyle.Render(p.NewBranch), p.Base, mode)
+		if p.Err != nil {
+			PrintError("%v", p.Err)
+			continue
+		}
+
+		if p.Blocked() {
+			PrintError("original repo has uncommitted changes: it would be skipped (use --isolated)")
+		} else if p.Dirty {
+			PrintInfo("original repo has uncommitted changes: left untouched")
+		}
+
+		if len(p.Commits) > 0 {
+			fmt.Fprintf(os.Stderr, "  %d commit(s):\n", len(p.Commits))
+			for _, c := range p.Commits {
+				fmt.Fprintf(os.Stderr, "    %s %s\n", WarningStyle.Render(shortHash(c.Hash)), c.Subject)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "  %d file(s):\n", len(p.Files))
+		for _, f := range p.Files {
+			fmt.Fprintf(os.Stderr, "    %s\n", f)
+		}
+
+		switch {
+		case p.ConflictCheck != "":
+			PrintWarning("conflicts could not be predicted: %s", p.ConflictCheck)
+		case len(p.Conflicts) > 0:
+			PrintError("would conflict: %s", strings.Join(p.Conflicts, ", "))
+		default:
+			PrintSuccess("no conflicts expected")
+		}
+	}
+}
+
+// IsTerminal reports whether f is attached to a terminal.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Progress renders one live status line per item on stderr.
+// When stderr is not a terminal, nothing is drawn and callers are expected
+// to report results themselves once all items are done.
+type Progress struct {
+	mu     sync.Mutex
+	labels []string
+	states []string
+	live   bool
+	drawn  bool
+}
+
+// NewProgress creates a progress display with one line per label.
+func NewProgress(labels []string) *Progress {
+	states := make([]string, len(labels))
+	for i := range states {
+		states[i] = DimStyle.Render("waiting")
+	}
+	p := &Progress{
+		labels: labels,
+		states: states,
+		live:   IsTerminal(os.Stderr),
+	}
+	p.mu.Lock()
+	p.draw()
+	p.mu.Unlock()
+	return p
+}
+
+// Update sets the status text of item i and redraws the display.
+func (p *Progress) Update(i int, state string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.states[i] = state
+	p.draw()
+}
+
+// Clear erases the progress lines so that final results can be printed in their place.
+func (p *Progress) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.live || !p.drawn {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\x1b[%dA\x1b[J", len(p.labels))
+	p.drawn = false
+}
+
+// draw redraws every line in place. The caller must hold p.mu.
+func (p *Progress) draw() {
+	if !p.live || len(p.labels) == 0 {
+		return
+	}
+	if p.drawn {
+		fmt.Fprintf(os.Stderr, "\x1b[%dA", len(p.labels))
+	}
+	for i, label := range p.labels {
+		fmt.Fprintf(os.Stderr, "\x1b[2K  %s %s  %s\n", InfoStyle.Render("\u2026"), RepoNameStyle.Render(label), p.states[i])
+	}
+	p.drawn = true
+}
+
 // joinParts joins string parts with " and ".
 func joinParts(parts []string) string {
 	if len(parts) == 0 {
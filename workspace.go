@@ -1,30 +1,34 @@
 package mangrove
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 )
 
 // WorkspaceInfo represents summary info about a workspace.
 type WorkspaceInfo struct {
-	ProfileName   string
-	WorkspaceName string
-	Path          string
-	RepoStatuses  []RepoStatus
+	ProfileName   string             `json:"profile"            yaml:"profile"`
+	WorkspaceName string             `json:"name"               yaml:"name"`
+	Path          string             `json:"path"               yaml:"path"`
+	Metadata      *WorkspaceMetadata `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	RepoStatuses  []RepoStatus       `json:"repos"              yaml:"repos"`
 }
 
 // RepoStatus represents the status of a single repo within a workspace.
 type RepoStatus struct {
-	RepoName     string
-	BranchName   string
-	ChangedCount int
-	Ahead        int
-	Behind       int
-	DefaultBase  string
-	Exists       bool
+	RepoName     string `json:"repo"    yaml:"repo"`
+	BranchName   string `json:"branch"  yaml:"branch"`
+	ChangedCount int    `json:"changed" yaml:"changed"`
+	Ahead        int    `json:"ahead"   yaml:"ahead"`
+	Behind       int    `json:"behind"  yaml:"behind"`
+	DefaultBase  string `json:"base"    yaml:"base"`
+	Exists       bool   `json:"exists"  yaml:"exists"`
 }
 
 // GetWorkspacePath returns the full path for a workspace.
@@ -32,8 +36,103 @@ func GetWorkspacePath(cfg *Config, profileName, name string) string {
 	return filepath.Join(cfg.BaseDir, profileName, name)
 }
 
+// ValidateWorkspaceName returns an error if name cannot be used as a workspace
+// directory under the profile directory, e.g. because it contains a path separator.
+func ValidateWorkspaceName(name string) error {
+	if name == "" || name == "." || strings.Contains(name, "..") ||
+		strings.ContainsAny(name, `/\`) || filepath.Base(name) != name {
+		return fmt.Errorf("invalid workspace name %q: it must be a single directory name", name)
+	}
+	return nil
+}
+
+// Checkout modes describe how a repo's workspace branch is obtained.
+const (
+	// CheckoutNew creates a new branch from the base branch.
+	CheckoutNew = "new"
+	// CheckoutLocal attaches an existing local branch.
+	CheckoutLocal = "local"
+	// CheckoutRemote creates a local branch tracking origin/<branch>.
+	CheckoutRemote = "remote"
+)
+
+// WorktreeSource describes the branch a repo's worktree is created on.
+// A new branch starts at StartPoint when set, and at Base otherwise.
+type WorktreeSource struct {
+	Branch     string
+	Base       string
+	Mode       string
+	StartPoint string
+}
+
+// ResolveCheckout decides how to check out branch in a repo: attach it if it exists
+// locally, track origin/<branch> if it only exists on the remote, and otherwise
+// create it from base.
+func ResolveCheckout(repoPath, branch, base string) WorktreeSource {
+	switch {
+	case RefExists(repoPath, "refs/heads/"+branch):
+		return WorktreeSource{Branch: branch, Base: base, Mode: CheckoutLocal}
+	case RefExists(repoPath, "refs/remotes/origin/"+branch):
+		return WorktreeSource{Branch: branch, Base: base, Mode: CheckoutRemote}
+	default:
+		return WorktreeSource{Branch: branch, Base: base, Mode: CheckoutNew}
+	}
+}
+
+// CheckoutCandidates returns the sorted, de-duplicated set of local and origin
+// branch names across the given repos, for selecting a branch to check out.
+func CheckoutCandidates(repos []Repo) []string {
+	seen := make(map[string]bool)
+	for _, repo := range repos {
+		local, _ := BranchList(repo.Path)
+		remote, _ := RemoteBranchList(repo.Path)
+		for _, b := range local {
+			seen[b] = true
+		}
+		for _, b := range remote {
+			if name, ok := strings.CutPrefix(b, "origin/"); ok && name != "HEAD" && b != "origin" {
+				seen[name] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// addWorktree creates the worktree for a repo according to its source. A branch
+// created for the worktree is recorded with BranchSetWorktree so mgv doctor can find
+// it once the worktree is gone.
+func addWorktree(repoPath, worktreePath string, src WorktreeSource) error {
+	var err error
+	switch src.Mode {
+	case CheckoutLocal:
+		return WorktreeAddExisting(repoPath, worktreePath, src.Branch)
+	case CheckoutRemote:
+		err = WorktreeAddTracking(repoPath, worktreePath, src.Branch, "origin/"+src.Branch)
+	default:
+		start := src.Base
+		if src.StartPoint != "" {
+			start = src.StartPoint
+		}
+		err = WorktreeAdd(repoPath, worktreePath, src.Branch, start)
+	}
+	if err != nil {
+		return err
+	}
+	// The record only helps mgv doctor; the worktree is fine without it
+	_ = BranchSetWorktree(repoPath, src.Branch, worktreePath)
+	return nil
+}
+
 // CreateWorkspace creates a new workspace with worktrees for all repos in the profile.
-func CreateWorkspace(cfg *Config, profile *Profile, profileName, name string, baseBranches map[string]string) error {
+// sources maps repo names to the branch each worktree is created on; an empty Branch
+// is resolved from branch_template and an empty Base falls back to the repo's default base.
+func CreateWorkspace(cfg *Config, profile *Profile, profileName, name string, sources map[string]WorktreeSource) error {
 	wsPath := GetWorkspacePath(cfg, profileName, name)
 
 	// Check if workspace already exists
@@ -41,6 +140,12 @@ func CreateWorkspace(cfg *Config, profile *Profile, profileName, name string, ba
 		return fmt.Errorf("workspace %q already exists at %s", name, wsPath)
 	}
 
+	// Resolve the branch source for each repo
+	srcs, err := resolveSources(profile, profileName, name, sources)
+	if err != nil {
+		return err
+	}
+
 	// Create workspace directory
 	if err := os.MkdirAll(wsPath, 0o755); err != nil {
 		return fmt.Errorf("failed to create workspace directory: %w", err)
@@ -48,50 +153,169 @@ func CreateWorkspace(cfg *Config, profile *Profile, profileName, name string, ba
 
 	fmt.Fprintf(os.Stderr, "\nCreating workspace: %s/%s\n", profileName, name)
 
-	// Create worktrees for each repo
-	for _, repo := range profile.Repos {
-		base, ok := baseBranches[repo.Name]
-		if !ok {
-			base = repo.GetDefaultBase()
-		}
+	// Run pre_create hooks; per-repo hooks run in the original repos
+	preRecords, err := RunHooks(profile.Hooks.PreCreate, hookContext(HookPreCreate, profile, profileName, name, wsPath, srcs, true))
+	if err != nil {
+		_ = os.RemoveAll(wsPath)
+		return err
+	}
 
-		worktreePath := filepath.Join(wsPath, repo.Name)
+	// Create worktrees for all repos concurrently
+	baseCommits, err := createWorktrees(cfg, profile, wsPath, srcs)
+	if err != nil {
+		// Clean up on failure
+		cleanupWorkspace(profile, wsPath, srcs)
+		return err
+	}
 
-		if err := WorktreeAdd(repo.Path, worktreePath, name, base); err != nil {
-			// Clean up on failure
-			cleanupWorkspace(cfg, profile, profileName, name)
-			return fmt.Errorf("failed to create worktree for %s: %w", repo.Name, err)
+	meta := &WorkspaceMetadata{
+		Profile:    profileName,
+		Name:       name,
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+		MgvVersion: Version,
+		Hooks:      preRecords,
+	}
+	// Repos that are not part of the configured profile (ad-hoc repos carried over
+	// by a fork) are recorded with their path, as in AddRepos
+	configured := cfg.Profiles[profileName]
+	for i, repo := range profile.Repos {
+		rm := RepoMetadata{
+			Name:       repo.Name,
+			Branch:     srcs[i].Branch,
+			Base:       srcs[i].Base,
+			BaseCommit: baseCommits[i],
+		}
+		if FindRepo(configured.Repos, repo.Name) == nil {
+			rm.Path = repo.Path
 		}
+		meta.Repos = append(meta.Repos, rm)
+		printWorktreeSource(repo.Name, srcs[i])
+	}
 
-		PrintSuccess("%s  %s \u2192 %s",
-			RepoNameStyle.Render(repo.Name),
-			BranchNameStyle.Render(base),
-			BranchNameStyle.Render(name),
-		)
+	if err := SaveWorkspaceMetadata(wsPath, meta); err != nil {
+		cleanupWorkspace(profile, wsPath, srcs)
+		return err
 	}
 
 	// Run post_create hooks
-	if len(profile.Hooks.PostCreate) > 0 {
-		PrintSuccess("Running post_create hooks...")
-		for _, hook := range profile.Hooks.PostCreate {
-			hookDir := filepath.Join(wsPath, hook.Repo)
-			if _, err := os.Stat(hookDir); os.IsNotExist(err) {
-				PrintWarning("Skipping hook for %s: directory not found", hook.Repo)
-				continue
-			}
+	records, err := RunHooks(profile.Hooks.PostCreate, hookContext(HookPostCreate, profile, profileName, name, wsPath, srcs, false))
+	meta.Hooks = append(meta.Hooks, records...)
+	if len(records) > 0 {
+		if err := SaveWorkspaceMetadata(wsPath, meta); err != nil {
+			PrintWarning("Failed to record hooks: %v", err)
+		}
+	}
+	if err != nil {
+		var hookErr *HookError
+		if errors.As(err, &hookErr) && hookErr.Rollback() {
+			PrintWarning("Rolling back workspace %s/%s...", profileName, name)
+			cleanupWorkspace(profile, wsPath, srcs)
+		}
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "\nWorkspace ready: %s\n", wsPath)
+	return nil
+}
+
+// AddRepos adds worktrees for repos to an existing workspace and records them in its
+// metadata. Repos that are not part of the profile (ad-hoc paths) are recorded with
+// their path so later commands can find them. sources works as in CreateWorkspace.
+// Only per-repo hooks run, since the workspace itself already exists.
+func AddRepos(cfg *Config, profile *Profile, profileName, name string, repos []Repo, sources map[string]WorktreeSource) error {
+	wsPath := GetWorkspacePath(cfg, profileName, name)
+
+	if _, err := os.Stat(wsPath); os.IsNotExist(err) {
+		return fmt.Errorf("workspace %q not found at %s", name, wsPath)
+	}
+
+	meta, err := LoadWorkspaceMetadata(wsPath)
+	if err != nil {
+		return err
+	}
+	if meta == nil {
+		if meta, err = newWorkspaceMetadata(profile, profileName, name, wsPath); err != nil {
+			return err
+		}
+	}
+
+	for _, repo := range repos {
+		// An ad-hoc repo named like a profile repo would later resolve to the profile's repo
+		if pr := FindRepo(profile.Repos, repo.Name); pr != nil && filepath.Clean(pr.Path) != filepath.Clean(repo.Path) {
+			return fmt.Errorf("profile %q already has a repo named %s at %s. Use --name to add %s under another name", profileName, repo.Name, pr.Path, repo.Path)
+		}
+		if meta.FindRepo(repo.Name) != nil {
+			return fmt.Errorf("%s is already part of workspace %q", repo.Name, name)
+		}
+		if _, err := os.Stat(filepath.Join(wsPath, repo.Name)); err == nil {
+			return fmt.Errorf("%s already exists in workspace %q", repo.Name, name)
+		}
+	}
+
+	subset := *profile
+	subset.Repos = repos
+	hooks := repoHooks(profile.Hooks)
+
+	srcs, err := resolveSources(&subset, profileName, name, sources)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "\nAdding repos to workspace: %s/%s\n", profileName, name)
+
+	preRecords, err := RunHooks(hooks.PreCreate, hookContext(HookPreCreate, &subset, profileName, name, wsPath, srcs, true))
+	if err != nil {
+		return err
+	}
+
+	baseCommits, err := createWorktrees(cfg, &subset, wsPath, srcs)
+	if err != nil {
+		cleanupWorktrees(&subset, wsPath, srcs)
+		return err
+	}
+
+	for i, repo := range repos {
+		rm := RepoMetadata{
+			Name:       repo.Name,
+			Branch:     srcs[i].Branch,
+			Base:       srcs[i].Base,
+			BaseCommit: baseCommits[i],
+		}
+		if FindRepo(profile.Repos, repo.Name) == nil {
+			rm.Path = repo.Path
+		}
+		meta.Repos = append(meta.Repos, rm)
+		printWorktreeSource(repo.Name, srcs[i])
+	}
+	meta.Hooks = append(meta.Hooks, preRecords...)
 
-			cmd := exec.Command("sh", "-c", hook.Run)
-			cmd.Dir = hookDir
-			cmd.Stdout = os.Stderr
-			cmd.Stderr = os.Stderr
+	if err := SaveWorkspaceMetadata(wsPath, meta); err != nil {
+		cleanupWorktrees(&subset, wsPath, srcs)
+		return err
+	}
 
-			if err := cmd.Run(); err != nil {
-				PrintWarning("Hook failed for %s (%s): %v", hook.Repo, hook.Run, err)
+	records, err := RunHooks(hooks.PostCreate, hookContext(HookPostCreate, &subset, profileName, name, wsPath, srcs, false))
+	if len(records) > 0 {
+		meta.Hooks = append(meta.Hooks, records...)
+		if err := SaveWorkspaceMetadata(wsPath, meta); err != nil {
+			PrintWarning("Failed to record hooks: %v", err)
+		}
+	}
+	if err != nil {
+		var hookErr *HookError
+		if errors.As(err, &hookErr) && hookErr.Rollback() {
+			PrintWarning("Rolling back added repos...")
+			cleanupWorktrees(&subset, wsPath, srcs)
+			for _, repo := range repos {
+				meta.RemoveRepo(repo.Name)
+			}
+			if err := SaveWorkspaceMetadata(wsPath, meta); err != nil {
+				PrintWarning("Failed to update metadata: %v", err)
 			}
 		}
+		return err
 	}
 
-	fmt.Fprintf(os.Stderr, "\nWorkspace ready: %s\n", wsPath)
 	return nil
 }
 
@@ -104,26 +328,181 @@ func RemoveWorkspace(cfg *Config, profile *Profile, profileName, name string, de
 		return fmt.Errorf("workspace %q not found at %s", name, wsPath)
 	}
 
-	// If not forcing, check for uncommitted changes
+	meta, err := LoadWorkspaceMetadata(wsPath)
+	if err != nil {
+		PrintWarning("%v", err)
+	}
+
+	// Only the repos that are part of this workspace are removed
+	wsProfile := *profile
+	wsProfile.Repos = WorkspaceRepos(meta, profile)
+
+	// If not forcing, check for an unfinished sync or apply and uncommitted changes
 	if !force {
-		for _, repo := range profile.Repos {
-			repoDir := filepath.Join(wsPath, repo.Name)
-			if _, err := os.Stat(repoDir); os.IsNotExist(err) {
-				continue
-			}
-			count, err := StatusChangedCount(repoDir)
-			if err != nil {
-				continue
-			}
-			if count > 0 {
-				return fmt.Errorf("%s has uncommitted changes (%d files). Use --force to remove anyway", repo.Name, count)
-			}
+		if err := CheckInProgress(wsPath, name); err != nil {
+			return fmt.Errorf("%w, or use --force to remove anyway", err)
+		}
+		if err := checkUncommitted(wsPath, wsProfile.Repos); err != nil {
+			return err
 		}
 	}
 
+	srcs, err := workspaceSources(meta, &wsProfile, profileName, name)
+	if err != nil {
+		return err
+	}
+
 	fmt.Fprintf(os.Stderr, "\nRemoving workspace: %s/%s\n", profileName, name)
 
-	for _, repo := range profile.Repos {
+	// Run pre_remove hooks; a failing abort/rollback hook keeps the workspace intact
+	if _, err := RunHooks(profile.Hooks.PreRemove, hookContext(HookPreRemove, &wsProfile, profileName, name, wsPath, srcs, false)); err != nil {
+		return err
+	}
+
+	removeWorktrees(&wsProfile, wsPath, srcs, deleteBranch, force)
+
+	// Remove workspace directory
+	if err := os.RemoveAll(wsPath); err != nil {
+		return fmt.Errorf("failed to remove workspace directory: %w", err)
+	}
+
+	PrintSuccess("Directory cleaned up")
+
+	// Run post_remove hooks; per-repo hooks run in the original repos and
+	// workspace-level hooks in the profile directory, since the workspace is gone
+	postCtx := hookContext(HookPostRemove, &wsProfile, profileName, name, wsPath, srcs, true)
+	postCtx.Dir = filepath.Dir(wsPath)
+	if _, err := RunHooks(profile.Hooks.PostRemove, postCtx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RemoveRepos removes the worktrees of some repos from a workspace, keeping the
+// workspace and its other repos, and optionally deletes their branches.
+// Only per-repo hooks run, since the workspace itself stays in place.
+func RemoveRepos(cfg *Config, profile *Profile, profileName, name string, repos []Repo, deleteBranch, force bool) error {
+	wsPath := GetWorkspacePath(cfg, profileName, name)
+
+	if _, err := os.Stat(wsPath); os.IsNotExist(err) {
+		return fmt.Errorf("workspace %q not found at %s", name, wsPath)
+	}
+
+	meta, err := LoadWorkspaceMetadata(wsPath)
+	if err != nil {
+		return err
+	}
+	if meta == nil {
+		// Record the current membership so the removed repos are not reported as missing later
+		if meta, err = newWorkspaceMetadata(profile, profileName, name, wsPath); err != nil {
+			return err
+		}
+	}
+
+	if !force {
+		if err := CheckInProgress(wsPath, name); err != nil {
+			return fmt.Errorf("%w, or use --force to remove anyway", err)
+		}
+		if err := checkUncommitted(wsPath, repos); err != nil {
+			return err
+		}
+	}
+
+	subset := *profile
+	subset.Repos = repos
+	hooks := repoHooks(profile.Hooks)
+	srcs, err := workspaceSources(meta, &subset, profileName, name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "\nRemoving repos from workspace: %s/%s\n", profileName, name)
+
+	if _, err := RunHooks(hooks.PreRemove, hookContext(HookPreRemove, &subset, profileName, name, wsPath, srcs, false)); err != nil {
+		return err
+	}
+
+	// Repos whose worktree is still there stay part of the workspace
+	failed := removeWorktrees(&subset, wsPath, srcs, deleteBranch, force)
+	removed := subset
+	removed.Repos = nil
+	var removedSrcs []WorktreeSource
+	for i, repo := range repos {
+		if slices.Contains(failed, repo.Name) {
+			continue
+		}
+		meta.RemoveRepo(repo.Name)
+		removed.Repos = append(removed.Repos, repo)
+		removedSrcs = append(removedSrcs, srcs[i])
+	}
+	if err := SaveWorkspaceMetadata(wsPath, meta); err != nil {
+		return err
+	}
+
+	if _, err := RunHooks(hooks.PostRemove, hookContext(HookPostRemove, &removed, profileName, name, wsPath, removedSrcs, true)); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to remove the worktrees of %s; they are kept in the workspace", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// CheckInProgress returns an error if a sync or an apply is in progress in a workspace.
+func CheckInProgress(wsPath, name string) error {
+	if state, err := LoadSyncState(wsPath); err != nil {
+		return err
+	} else if state != nil {
+		return fmt.Errorf("a sync is in progress in %q. Finish it with mgv sync --continue or --abort first", name)
+	}
+	if state, err := LoadApplyState(wsPath); err != nil {
+		return err
+	} else if state != nil {
+		return fmt.Errorf("an apply is in progress in %q. Finish it with mgv apply --continue or --abort first", name)
+	}
+	return nil
+}
+
+// checkUncommitted returns an error if any of the repos' worktrees has uncommitted changes.
+func checkUncommitted(wsPath string, repos []Repo) error {
+	for _, repo := range repos {
+		repoDir := filepath.Join(wsPath, repo.Name)
+		if _, err := os.Stat(repoDir); os.IsNotExist(err) {
+			continue
+		}
+		count, err := StatusChangedCount(repoDir)
+		if err != nil {
+			return fmt.Errorf("%s: cannot check for uncommitted changes: %w. Use --force to remove anyway", repo.Name, err)
+		}
+		if count > 0 {
+			return fmt.Errorf("%s has uncommitted changes (%d files). Use --force to remove anyway", repo.Name, count)
+		}
+	}
+	return nil
+}
+
+// workspaceSources returns the branch and base of each profile repo in an existing workspace.
+func workspaceSources(meta *WorkspaceMetadata, profile *Profile, profileName, name string) ([]WorktreeSource, error) {
+	srcs := make([]WorktreeSource, len(profile.Repos))
+	for i, repo := range profile.Repos {
+		branch, err := WorkspaceBranch(meta, profile, &repo, profileName, name)
+		if err != nil {
+			return nil, err
+		}
+		srcs[i] = WorktreeSource{
+			Branch: branch,
+			Base:   meta.RepoBase(&repo),
+		}
+	}
+	return srcs, nil
+}
+
+// removeWorktrees removes the worktree of each profile repo and optionally its branch.
+// Failures are reported and do not stop the remaining repos. It returns the names of
+// the repos whose worktree could not be removed.
+func removeWorktrees(profile *Profile, wsPath string, srcs []WorktreeSource, deleteBranch, force bool) []string {
+	var failed []string
+	for i, repo := range profile.Repos {
 		repoDir := filepath.Join(wsPath, repo.Name)
 		if _, err := os.Stat(repoDir); os.IsNotExist(err) {
 			continue
@@ -132,6 +511,7 @@ func RemoveWorkspace(cfg *Config, profile *Profile, profileName, name string, de
 		// Remove worktree
 		if err := WorktreeRemove(repo.Path, repoDir, force); err != nil {
 			PrintError("%s  worktree removal failed: %v", repo.Name, err)
+			failed = append(failed, repo.Name)
 			continue
 		}
 
@@ -139,7 +519,7 @@ func RemoveWorkspace(cfg *Config, profile *Profile, profileName, name string, de
 
 		// Delete branch if requested
 		if deleteBranch {
-			if err := BranchDelete(repo.Path, name, force); err != nil {
+			if err := BranchDelete(repo.Path, srcs[i].Branch, force); err != nil {
 				PrintWarning("%s  worktree removed, branch deletion failed: %v", repo.Name, err)
 			} else {
 				msg = "worktree removed, branch deleted"
@@ -148,14 +528,52 @@ func RemoveWorkspace(cfg *Config, profile *Profile, profileName, name string, de
 
 		PrintSuccess("%s  %s", RepoNameStyle.Render(repo.Name), msg)
 	}
+	return failed
+}
 
-	// Remove workspace directory
-	if err := os.RemoveAll(wsPath); err != nil {
-		return fmt.Errorf("failed to remove workspace directory: %w", err)
+// newWorkspaceMetadata builds metadata for a workspace that has none, assuming it
+// contains every profile repo that has a worktree, on its template branch.
+func newWorkspaceMetadata(profile *Profile, profileName, name, wsPath string) (*WorkspaceMetadata, error) {
+	meta := &WorkspaceMetadata{
+		Profile:    profileName,
+		Name:       name,
+		MgvVersion: Version,
 	}
+	for _, repo := range profile.Repos {
+		if _, err := os.Stat(filepath.Join(wsPath, repo.Name)); err != nil {
+			continue
+		}
+		branch, err := WorkspaceBranch(nil, profile, &repo, profileName, name)
+		if err != nil {
+			return nil, err
+		}
+		meta.Repos = append(meta.Repos, RepoMetadata{
+			Name:   repo.Name,
+			Branch: branch,
+			Base:   repo.GetDefaultBase(),
+		})
+	}
+	return meta, nil
+}
 
-	PrintSuccess("Directory cleaned up")
-	return nil
+// repoHooks returns only the per-repo hooks of each stage.
+func repoHooks(h Hooks) Hooks {
+	filter := func(hooks []Hook) []Hook {
+		var out []Hook
+		for _, hook := range hooks {
+			if hook.Repo != "" {
+				out = append(out, hook)
+			}
+		}
+		return out
+	}
+	return Hooks{
+		PreCreate:  filter(h.PreCreate),
+		PostCreate: filter(h.PostCreate),
+		PreRemove:  filter(h.PreRemove),
+		PostRemove: filter(h.PostRemove),
+		PostApply:  filter(h.PostApply),
+	}
 }
 
 // ListWorkspaces scans the base_dir for workspaces and returns their info.
@@ -163,15 +581,9 @@ func RemoveWorkspace(cfg *Config, profile *Profile, profileName, name string, de
 func ListWorkspaces(cfg *Config, profileName string) ([]WorkspaceInfo, error) {
 	var workspaces []WorkspaceInfo
 
-	profilesToScan := make(map[string]Profile)
-	if profileName != "" {
-		profile, ok := cfg.Profiles[profileName]
-		if !ok {
-			return nil, fmt.Errorf("profile %q not found", profileName)
-		}
-		profilesToScan[profileName] = profile
-	} else {
-		profilesToScan = cfg.Profiles
+	profilesToScan, err := scanProfiles(cfg, profileName)
+	if err != nil {
+		return nil, err
 	}
 
 	for pName, profile := range profilesToScan {
@@ -190,54 +602,81 @@ func ListWorkspaces(cfg *Config, profileName string) ([]WorkspaceInfo, error) {
 				continue
 			}
 
-			wsName := entry.Name()
-			wsPath := filepath.Join(profileDir, wsName)
+			ws := LoadWorkspaceInfo(cfg, &profile, pName, entry.Name())
+			workspaces = append(workspaces, ws)
+		}
+	}
 
-			ws := WorkspaceInfo{
-				ProfileName:   pName,
-				WorkspaceName: wsName,
-				Path:          wsPath,
-			}
+	return workspaces, nil
+}
+
+// scanProfiles returns the named profile, or all profiles if profileName is empty.
+func scanProfiles(cfg *Config, profileName string) (map[string]Profile, error) {
+	if profileName == "" {
+		return cfg.Profiles, nil
+	}
+	profile, ok := cfg.Profiles[profileName]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found", profileName)
+	}
+	return map[string]Profile{profileName: profile}, nil
+}
+
+// LoadWorkspaceInfo collects the metadata and per-repo status of a single workspace.
+func LoadWorkspaceInfo(cfg *Config, profile *Profile, profileName, wsName string) WorkspaceInfo {
+	wsPath := GetWorkspacePath(cfg, profileName, wsName)
+
+	meta, err := LoadWorkspaceMetadata(wsPath)
+	if err != nil {
+		PrintWarning("%s/%s: %v", profileName, wsName, err)
+	}
+
+	ws := WorkspaceInfo{
+		ProfileName:   profileName,
+		WorkspaceName: wsName,
+		Path:          wsPath,
+		Metadata:      meta,
+		RepoStatuses:  []RepoStatus{},
+	}
+
+	for _, repo := range WorkspaceRepos(meta, profile) {
+		repoDir := filepath.Join(wsPath, repo.Name)
+		rs := RepoStatus{
+			RepoName:    repo.Name,
+			DefaultBase: meta.RepoBase(&repo),
+		}
+
+		if _, err := os.Stat(repoDir); os.IsNotExist(err) {
+			rs.Exists = false
+			ws.RepoStatuses = append(ws.RepoStatuses, rs)
+			continue
+		}
 
-			for _, repo := range profile.Repos {
-				repoDir := filepath.Join(wsPath, repo.Name)
-				rs := RepoStatus{
-					RepoName:    repo.Name,
-					DefaultBase: repo.GetDefaultBase(),
-				}
-
-				if _, err := os.Stat(repoDir); os.IsNotExist(err) {
-					rs.Exists = false
-					ws.RepoStatuses = append(ws.RepoStatuses, rs)
-					continue
-				}
-
-				rs.Exists = true
-
-				branch, err := CurrentBranch(repoDir)
-				if err == nil {
-					rs.BranchName = branch
-				}
-
-				count, err := StatusChangedCount(repoDir)
-				if err == nil {
-					rs.ChangedCount = count
-				}
-
-				ahead, behind, err := AheadBehind(repo.Path, rs.DefaultBase, branch)
-				if err == nil {
-					rs.Ahead = ahead
-					rs.Behind = behind
-				}
-
-				ws.RepoStatuses = append(ws.RepoStatuses, rs)
+		rs.Exists = true
+
+		branch, err := CurrentBranch(repoDir)
+		if err != nil {
+			if branch, err = WorkspaceBranch(meta, profile, &repo, profileName, wsName); err != nil {
+				PrintWarning("%s/%s/%s: %v", profileName, wsName, repo.Name, err)
 			}
+		}
+		rs.BranchName = branch
 
-			workspaces = append(workspaces, ws)
+		count, err := StatusChangedCount(repoDir)
+		if err == nil {
+			rs.ChangedCount = count
+		}
+
+		ahead, behind, err := AheadBehind(repo.Path, rs.DefaultBase, branch)
+		if err == nil {
+			rs.Ahead = ahead
+			rs.Behind = behind
 		}
+
+		ws.RepoStatuses = append(ws.RepoStatuses, rs)
 	}
 
-	return workspaces, nil
+	return ws
 }
 
 // WorkspaceLabels returns a list of formatted workspace labels for fzf selection.
@@ -273,14 +712,127 @@ func ParseWorkspaceLabel(label string) (profileName, workspaceName string, err e
 	return slashParts[0], slashParts[1], nil
 }
 
-// cleanupWorkspace attempts to clean up a partially created workspace.
-func cleanupWorkspace(cfg *Config, profile *Profile, profileName, name string) {
-	wsPath := GetWorkspacePath(cfg, profileName, name)
-	for _, repo := range profile.Repos {
-		repoDir := filepath.Join(wsPath, repo.Name)
-		if _, err := os.Stat(repoDir); err == nil {
-			_ = WorktreeRemove(repo.Path, repoDir, true)
+// hookContext builds the hook context for a workspace. Per-repo hooks run in the
+// worktrees, or in the original repos when inOriginal is set (e.g., before the
+// worktrees exist).
+func hookContext(stage string, profile *Profile, profileName, name, wsPath string, srcs []WorktreeSource, inOriginal bool) HookContext {
+	ctx := HookContext{
+		Stage:         stage,
+		Profile:       profileName,
+		Workspace:     name,
+		WorkspacePath: wsPath,
+		Dir:           wsPath,
+	}
+	for i, repo := range profile.Repos {
+		dir := filepath.Join(wsPath, repo.Name)
+		if inOriginal {
+			dir = repo.Path
+		}
+		ctx.Repos = append(ctx.Repos, HookRepo{
+			Name:   repo.Name,
+			Branch: srcs[i].Branch,
+			Base:   srcs[i].Base,
+			Dir:    dir,
+		})
+	}
+	return ctx
+}
+
+// resolveSources returns the worktree source of each profile repo, resolving an
+// empty branch from branch_template and an empty base from the repo's default base.
+func resolveSources(profile *Profile, profileName, name string, sources map[string]WorktreeSource) ([]WorktreeSource, error) {
+	srcs := make([]WorktreeSource, len(profile.Repos))
+	for i, repo := range profile.Repos {
+		src, ok := sources[repo.Name]
+		if !ok {
+			src = WorktreeSource{Mode: CheckoutNew}
 		}
+		if src.Branch == "" {
+			branch, err := profile.BranchName(&repo, profileName, name)
+			if err != nil {
+				return nil, err
+			}
+			src.Branch = branch
+		}
+		if src.Base == "" {
+			src.Base = repo.GetDefaultBase()
+		}
+		srcs[i] = src
 	}
+	return srcs, nil
+}
+
+// createWorktrees creates the worktree of each profile repo concurrently, showing
+// progress, and returns the commit each branch forked from its base.
+func createWorktrees(cfg *Config, profile *Profile, wsPath string, srcs []WorktreeSource) ([]string, error) {
+	progress := NewProgress(RepoNames(profile.Repos))
+	errs := make([]error, len(profile.Repos))
+	baseCommits := make([]string, len(profile.Repos))
+
+	RunParallel(len(profile.Repos), cfg.GetParallelism(), func(i int) {
+		repo := profile.Repos[i]
+		progress.Update(i, DimStyle.Render("creating worktree..."))
+
+		worktreePath := filepath.Join(wsPath, repo.Name)
+		if err := addWorktree(repo.Path, worktreePath, srcs[i]); err != nil {
+			errs[i] = fmt.Errorf("failed to create worktree for %s: %w", repo.Name, err)
+			progress.Update(i, ErrorStyle.Render("failed"))
+			return
+		}
+		if commit, err := MergeBase(worktreePath, srcs[i].Base, "HEAD"); err == nil {
+			baseCommits[i] = commit
+		}
+		progress.Update(i, SuccessStyle.Render("done"))
+	})
+	progress.Clear()
+
+	return baseCommits, errors.Join(errs...)
+}
+
+// printWorktreeSource reports where a repo's worktree branch came from.
+func printWorktreeSource(repoName string, src WorktreeSource) {
+	switch src.Mode {
+	case CheckoutLocal:
+		PrintSuccess("%s  %s %s",
+			RepoNameStyle.Render(repoName),
+			BranchNameStyle.Render(src.Branch),
+			DimStyle.Render("(existing branch)"),
+		)
+	case CheckoutRemote:
+		PrintSuccess("%s  %s \u2192 %s",
+			RepoNameStyle.Render(repoName),
+			BranchNameStyle.Render("origin/"+src.Branch),
+			BranchNameStyle.Render(src.Branch),
+		)
+	default:
+		PrintSuccess("%s  %s \u2192 %s",
+			RepoNameStyle.Render(repoName),
+			BranchNameStyle.Render(src.Base),
+			BranchNameStyle.Render(src.Branch),
+		)
+	}
+}
+
+// cleanupWorkspace attempts to clean up a partially created workspace,
+// deleting any branches that were created for it.
+func cleanupWorkspace(profile *Profile, wsPath string, srcs []WorktreeSource) {
+	cleanupWorktrees(profile, wsPath, srcs)
 	_ = os.RemoveAll(wsPath)
 }
+
+// cleanupWorktrees removes the worktrees of the profile repos, deleting any
+// branches that were created for them.
+func cleanupWorktrees(profile *Profile, wsPath string, srcs []WorktreeSource) {
+	for i, repo := range profile.Repos {
+		repoDir := filepath.Join(wsPath, repo.Name)
+		if _, err := os.Stat(repoDir); err != nil {
+			continue
+		}
+		if err := WorktreeRemove(repo.Path, repoDir, true); err != nil {
+			continue
+		}
+		if srcs[i].Mode != CheckoutLocal {
+			_ = BranchDelete(repo.Path, srcs[i].Branch, true)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package mangrove
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateWorkspace(t *testing.T) {
+	repos := []Repo{
+		{Name: "frontend", Path: newTestRepo(t)},
+		{Name: "backend", Path: newTestRepo(t)},
+		{Name: "infra", Path: newTestRepo(t)},
+	}
+	cfg := &Config{BaseDir: t.TempDir(), Parallel: 2}
+	profile := &Profile{Repos: repos}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "feature-x", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+
+	wsPath := GetWorkspacePath(cfg, "proj", "feature-x")
+	for _, repo := range repos {
+		branch, err := CurrentBranch(filepath.Join(wsPath, repo.Name))
+		if err != nil {
+			t.Fatalf("CurrentBranch(%s) unexpected error: %v", repo.Name, err)
+		}
+		if branch != "feature-x" {
+			t.Errorf("%s branch = %q, want %q", repo.Name, branch, "feature-x")
+		}
+	}
+
+	meta, err := LoadWorkspaceMetadata(wsPath)
+	if err != nil || meta == nil {
+		t.Fatalf("LoadWorkspaceMetadata() = %v, %v; want metadata", meta, err)
+	}
+	if meta.Profile != "proj" || meta.Name != "feature-x" {
+		t.Errorf("metadata profile/name = %q/%q, want proj/feature-x", meta.Profile, meta.Name)
+	}
+	if len(meta.Repos) != len(repos) {
+		t.Fatalf("metadata has %d repos, want %d", len(meta.Repos), len(repos))
+	}
+	for i, rm := range meta.Repos {
+		if rm.Name != repos[i].Name || rm.Base != "main" {
+			t.Errorf("metadata repo[%d] = %+v, want name %q base main", i, rm, repos[i].Name)
+		}
+		want, _ := RevParse(repos[i].Path, "main")
+		if rm.BaseCommit != want {
+			t.Errorf("metadata repo[%d] base_commit = %q, want %q", i, rm.BaseCommit, want)
+		}
+	}
+}
+
+func TestCreateWorkspaceCleansUpOnFailure(t *testing.T) {
+	good := newTestRepo(t)
+	repos := []Repo{
+		{Name: "good", Path: good},
+		{Name: "bad", Path: newTestRepo(t), DefaultBase: "does-not-exist"},
+	}
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "feature-x", nil); err == nil {
+		t.Fatal("CreateWorkspace() expected error for missing base branch")
+	}
+
+	wsPath := GetWorkspacePath(cfg, "proj", "feature-x")
+	if _, err := os.Stat(wsPath); !os.IsNotExist(err) {
+		t.Errorf("workspace directory %s should be removed after failure", wsPath)
+	}
+
+	entries, err := WorktreeList(good)
+	if err != nil {
+		t.Fatalf("WorktreeList() unexpected error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("good repo has %d worktrees after cleanup, want 1 (main only)", len(entries))
+	}
+}
+
+func TestCreateWorkspaceCheckout(t *testing.T) {
+	// local: branch exists locally
+	local := newTestRepo(t)
+	runGit(t, local, "branch", "feature/shared")
+
+	// remote: branch only exists on origin
+	upstream := newTestRepo(t)
+	runGit(t, upstream, "branch", "feature/shared")
+	remote := filepath.Join(t.TempDir(), "remote")
+	runGit(t, upstream, "clone", "-q", upstream, remote)
+
+	// missing: branch does not exist anywhere
+	missing := newTestRepo(t)
+
+	repos := []Repo{
+		{Name: "local", Path: local},
+		{Name: "remote", Path: remote},
+		{Name: "missing", Path: missing},
+	}
+
+	sources := make(map[string]WorktreeSource)
+	wantModes := map[string]string{"local": CheckoutLocal, "remote": CheckoutRemote, "missing": CheckoutNew}
+	for _, repo := range repos {
+		src := ResolveCheckout(repo.Path, "feature/shared", "main")
+		if src.Mode != wantModes[repo.Name] {
+			t.Errorf("ResolveCheckout(%s) mode = %q, want %q", repo.Name, src.Mode, wantModes[repo.Name])
+		}
+		sources[repo.Name] = src
+	}
+
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos}
+	if err := CreateWorkspace(cfg, profile, "proj", "review", sources); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+
+	wsPath := GetWorkspacePath(cfg, "proj", "review")
+	for _, repo := range repos {
+		branch, err := CurrentBranch(filepath.Join(wsPath, repo.Name))
+		if err != nil {
+			t.Fatalf("CurrentBranch(%s) unexpected error: %v", repo.Name, err)
+		}
+		if branch != "feature/shared" {
+			t.Errorf("%s branch = %q, want %q", repo.Name, branch, "feature/shared")
+		}
+	}
+
+	upstreamRef := runGit(t, filepath.Join(wsPath, "remote"), "rev-parse", "--abbrev-ref", "@{upstream}")
+	if upstreamRef != "origin/feature/shared" {
+		t.Errorf("remote repo upstream = %q, want %q", upstreamRef, "origin/feature/shared")
+	}
+
+	meta, _ := LoadWorkspaceMetadata(wsPath)
+	if got, err := WorkspaceBranch(meta, profile, &repos[0], "proj", "review"); err != nil || got != "feature/shared" {
+		t.Errorf("metadata branch = %q, %v; want %q", got, err, "feature/shared")
+	}
+}
+
+func TestCheckoutCandidates(t *testing.T) {
+	a := newTestRepo(t)
+	runGit(t, a, "branch", "feature/a")
+	upstream := newTestRepo(t)
+	runGit(t, upstream, "branch", "feature/b")
+	b := filepath.Join(t.TempDir(), "b")
+	runGit(t, upstream, "clone", "-q", upstream, b)
+
+	got := CheckoutCandidates([]Repo{{Name: "a", Path: a}, {Name: "b", Path: b}})
+	want := []string{"feature/a", "feature/b", "main"}
+	if len(got) != len(want) {
+		t.Fatalf("CheckoutCandidates() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("CheckoutCandidates()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCreateWorkspaceSubsetAndRemoveRepos(t *testing.T) {
+	repos := []Repo{
+		{Name: "frontend", Path: newTestRepo(t)},
+		{Name: "backend", Path: newTestRepo(t)},
+		{Name: "infra", Path: newTestRepo(t)},
+	}
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos}
+
+	// Create the workspace with only two of the profile's repos
+	subset := *profile
+	subset.Repos = []Repo{repos[0], repos[1]}
+	if err := CreateWorkspace(cfg, &subset, "proj", "feature-x", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+
+	wsPath := GetWorkspacePath(cfg, "proj", "feature-x")
+	if _, err := os.Stat(filepath.Join(wsPath, "infra")); !os.IsNotExist(err) {
+		t.Errorf("infra worktree should not be created")
+	}
+
+	info := LoadWorkspaceInfo(cfg, profile, "proj", "feature-x")
+	if len(info.RepoStatuses) != 2 {
+		t.Fatalf("LoadWorkspaceInfo() has %d repos, want 2", len(info.RepoStatuses))
+	}
+
+	// Removing one repo keeps the workspace and the other repo
+	if err := RemoveRepos(cfg, profile, "proj", "feature-x", []Repo{repos[0]}, true, false); err != nil {
+		t.Fatalf("RemoveRepos() unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(wsPath, "frontend")); !os.IsNotExist(err) {
+		t.Errorf("frontend worktree should be removed")
+	}
+	if RefExists(repos[0].Path, "refs/heads/feature-x") {
+		t.Errorf("frontend branch should be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(wsPath, "backend")); err != nil {
+		t.Errorf("backend worktree should remain: %v", err)
+	}
+
+	meta, err := LoadWorkspaceMetadata(wsPath)
+	if err != nil {
+		t.Fatalf("LoadWorkspaceMetadata() unexpected error: %v", err)
+	}
+	if got := RepoNames(WorkspaceRepos(meta, profile)); len(got) != 1 || got[0] != "backend" {
+		t.Errorf("workspace repos after RemoveRepos = %v, want [backend]", got)
+	}
+
+	if err := RemoveWorkspace(cfg, profile, "proj", "feature-x", true, false); err != nil {
+		t.Fatalf("RemoveWorkspace() unexpected error: %v", err)
+	}
+	if _, err := os.Stat(wsPath); !os.IsNotExist(err) {
+		t.Errorf("workspace directory should be removed")
+	}
+}
+
+func TestAddRepos(t *testing.T) {
+	repos := []Repo{
+		{Name: "frontend", Path: newTestRepo(t)},
+		{Name: "backend", Path: newTestRepo(t)},
+	}
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos}
+
+	subset := *profile
+	subset.Repos = repos[:1]
+	if err := CreateWorkspace(cfg, &subset, "proj", "feature-x", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+
+	// A profile repo and an ad-hoc repo outside the profile
+	adhoc := Repo{Name: "docs", Path: newTestRepo(t), DefaultBase: "main"}
+	if err := AddRepos(cfg, profile, "proj", "feature-x", []Repo{repos[1], adhoc}, nil); err != nil {
+		t.Fatalf("AddRepos() unexpected error: %v", err)
+	}
+
+	wsPath := GetWorkspacePath(cfg, "proj", "feature-x")
+	for _, name := range []string{"backend", "docs"} {
+		branch, err := CurrentBranch(filepath.Join(wsPath, name))
+		if err != nil {
+			t.Fatalf("CurrentBranch(%s) unexpected error: %v", name, err)
+		}
+		if branch != "feature-x" {
+			t.Errorf("%s branch = %q, want feature-x", name, branch)
+		}
+	}
+
+	meta, err := LoadWorkspaceMetadata(wsPath)
+	if err != nil {
+		t.Fatalf("LoadWorkspaceMetadata() unexpected error: %v", err)
+	}
+	if rm := meta.FindRepo("backend"); rm == nil || rm.Path != "" {
+		t.Errorf("backend metadata = %+v, want recorded without path", rm)
+	}
+	if rm := meta.FindRepo("docs"); rm == nil || rm.Path != adhoc.Path {
+		t.Errorf("docs metadata = %+v, want path %s", rm, adhoc.Path)
+	}
+
+	got := RepoNames(WorkspaceRepos(meta, profile))
+	want := []string{"frontend", "backend", "docs"}
+	if len(got) != len(want) {
+		t.Fatalf("WorkspaceRepos() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("WorkspaceRepos()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	// Adding a repo that is already part of the workspace fails
+	if err := AddRepos(cfg, profile, "proj", "feature-x", []Repo{repos[0]}, nil); err == nil {
+		t.Error("AddRepos() expected error for a repo already in the workspace")
+	}
+
+	// An ad-hoc repo named like a profile repo at another path is rejected
+	clash := Repo{Name: "frontend", Path: newTestRepo(t)}
+	if err := AddRepos(cfg, profile, "proj", "feature-x", []Repo{clash}, nil); err == nil || !strings.Contains(err.Error(), "--name") {
+		t.Errorf("AddRepos() error = %v, want a name clash suggesting --name", err)
+	}
+
+	// Removing the whole workspace also removes the ad-hoc worktree
+	if err := RemoveWorkspace(cfg, profile, "proj", "feature-x", true, false); err != nil {
+		t.Fatalf("RemoveWorkspace() unexpected error: %v", err)
+	}
+	entries, err := WorktreeList(adhoc.Path)
+	if err != nil {
+		t.Fatalf("WorktreeList() unexpected error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("docs repo has %d worktrees after removal, want 1", len(entries))
+	}
+}
+
+func TestRemoveWorkspaceInProgress(t *testing.T) {
+	repos := []Repo{
+		{Name: "frontend", Path: newTestRepo(t)},
+		{Name: "backend", Path: newTestRepo(t)},
+	}
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "feature-x", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	wsPath := GetWorkspacePath(cfg, "proj", "feature-x")
+
+	// An unfinished apply or sync blocks removal without --force
+	if err := saveApplyState(wsPath, &ApplyState{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveRepos(cfg, profile, "proj", "feature-x", []Repo{repos[0]}, false, false); err == nil || !strings.Contains(err.Error(), "apply is in progress") {
+		t.Errorf("RemoveRepos() error = %v, want an apply in progress", err)
+	}
+	if err := removeApplyState(wsPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveSyncState(wsPath, &SyncState{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveWorkspace(cfg, profile, "proj", "feature-x", false, false); err == nil || !strings.Contains(err.Error(), "sync is in progress") {
+		t.Errorf("RemoveWorkspace() error = %v, want a sync in progress", err)
+	}
+	if _, err := os.Stat(filepath.Join(wsPath, "frontend")); err != nil {
+		t.Errorf("frontend worktree should remain: %v", err)
+	}
+
+	if err := RemoveWorkspace(cfg, profile, "proj", "feature-x", false, true); err != nil {
+		t.Fatalf("RemoveWorkspace() with force unexpected error: %v", err)
+	}
+	if _, err := os.Stat(wsPath); !os.IsNotExist(err) {
+		t.Errorf("workspace directory should be removed")
+	}
+}
+
+func TestValidateWorkspaceName(t *testing.T) {
+	for _, name := range []string{"feature-x", "fix.login", "v1.2"} {
+		if err := ValidateWorkspaceName(name); err != nil {
+			t.Errorf("ValidateWorkspaceName(%q) unexpected error: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", ".", "..", "../x", "a/b", `a\b`, "/abs", "x/.."} {
+		if err := ValidateWorkspaceName(name); err == nil {
+			t.Errorf("ValidateWorkspaceName(%q) expected error", name)
+		}
+	}
+}
+
+func TestRemoveReposKeepsFailedRepos(t *testing.T) {
+	repos := []Repo{
+		{Name: "frontend", Path: newTestRepo(t)},
+		{Name: "backend", Path: newTestRepo(t)},
+	}
+	cfg := &Config{BaseDir: t.TempDir()}
+	profile := &Profile{Repos: repos}
+
+	if err := CreateWorkspace(cfg, profile, "proj", "feature-x", nil); err != nil {
+		t.Fatalf("CreateWorkspace() unexpected error: %v", err)
+	}
+	wsPath := GetWorkspacePath(cfg, "proj", "feature-x")
+
+	// A locked worktree cannot be removed
+	runGit(t, repos[0].Path, "worktree", "lock", filepath.Join(wsPath, "frontend"))
+	err := RemoveRepos(cfg, profile, "proj", "feature-x", repos, false, false)
+	if err == nil || !strings.Contains(err.Error(), "frontend") {
+		t.Errorf("RemoveRepos() error = %v, want frontend reported", err)
+	}
+
+	meta, err := LoadWorkspaceMetadata(wsPath)
+	if err != nil {
+		t.Fatalf("LoadWorkspaceMetadata() unexpected error: %v", err)
+	}
+	if got := RepoNames(WorkspaceRepos(meta, profile)); len(got) != 1 || got[0] != "frontend" {
+		t.Errorf("workspace repos after RemoveRepos = %v, want [frontend]", got)
+	}
+	if _, err := os.Stat(filepath.Join(wsPath, "backend")); !os.IsNotExist(err) {
+		t.Errorf("backend worktree should be removed")
+	}
+}